@@ -0,0 +1,1128 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Smart Contract COBRA framework
+//
+// version : 7.5
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - Implementation of RI (ReliabilityIndex) and TCI (TaskCostIndex) in task offloading
+//      - Addition of TaskOffloadFirstRoundRobin, TaskOffloadRandom, TaskOffloadSemiRandom, and TaskOffloadCobra functions
+//      - SC completed with all model 
+//      - The offload models, the leases and the queues are in the scheduler package, the contract
+//      only reads and writes the ledger
+//      - Package chaincode so the contract can also run in-process (ledger package), the main
+//      of the peer is in cobra_algo
+//      - The offload functions return the Assignment of the task: its TxID, its device and the
+//      battery of the device after the assignment
+//      - The offload functions take an optional request ID of the client, a request already
+//      offloaded returns its assignment instead of assigning the task again
+//      - The random draws are seeded with the TxID, so all the endorsing peers compute the same
+//      device and execution time
+//      - The assignment and the completion of a task set the events TaskAssigned and TaskCompleted
+//      - The State of the offload models is in the ledger (key STATE) instead of the memory of
+//      the peer, so the peers endorse with the same State
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+
+package chaincode
+
+import (
+    "encoding/json"
+    "fmt"
+    "hash/fnv"
+    "math/rand"
+    "strings"
+    "time"
+
+    "github.com/RezanOscar/COBRA/scheduler"
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Section 1 : Initial structure with the different basic funtion and structure                // 
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+type SmartContract struct {
+    contractapi.Contract
+}
+
+// StateKey is the key of the State of the offload models in the world state, out of the ranges of the devices,
+// the tasks and the requests
+const StateKey = "STATE"
+
+// The devices, tasks and device classes are the ones of the scheduler package
+type (
+    Device          = scheduler.Device
+    Reservation     = scheduler.Reservation
+    QueuedTask      = scheduler.QueuedTask
+    Task            = scheduler.Task
+    DeviceClass     = scheduler.DeviceClass
+    EnergyModel     = scheduler.EnergyModel
+    NetworkStats    = scheduler.NetworkStats
+    TypeStats       = scheduler.TypeStats
+    ReputationStats = scheduler.ReputationStats
+)
+
+// QueueStatus is the queue of a device returned by QueryQueues
+type QueueStatus struct {
+    DeviceID          string   `json:"deviceID"`
+    DeviceType        string   `json:"deviceType"`
+    Running           int      `json:"running"`          // Tasks holding compute resources
+    Queued            []string `json:"queued"`           // IDs of the waiting tasks in arrival order
+    Capacity          int      `json:"capacity"`         // Maximum number of waiting tasks
+    ExpectedWait      int64    `json:"expectedWait"`     // Estimated wait in ms before a new task behind the queue starts
+}
+
+// Error codes of the transactions, sent in a JSON payload so the clients can classify the failures
+const (
+    ErrNoCandidate         = "NO_CANDIDATE"         // No device can start or queue the task
+    ErrInsufficientBattery = "INSUFFICIENT_BATTERY" // No device can take the task because the batteries are too low
+    ErrUnknownTaskType     = "UNKNOWN_TASK_TYPE"    // The task type is not one of the 6G use cases
+    ErrUnauthorized        = "UNAUTHORIZED"         // The client is not allowed to change the device
+    ErrConflict            = "CONFLICT"             // The state of the device or of the task does not allow the operation
+)
+
+// Names of the chaincode events, a transaction sets at most one event like on Fabric where the last one is kept
+const (
+    EventTaskAssigned  = "TaskAssigned"  // A task is running or queued on a device, the payload is the Task in JSON
+    EventTaskCompleted = "TaskCompleted" // A device reported the end of a task, the payload is the Task in JSON
+)
+
+// Assignment is the result of an offload function: the task (its TaskID is the TxID) and its device after the
+// assignment
+type Assignment struct {
+    TaskID      string  `json:"taskID"`
+    TaskType    string  `json:"taskType"`
+    Status      string  `json:"status"`      // Running or Queued on the device
+    DeviceID    string  `json:"deviceID"`
+    DeviceType  string  `json:"deviceType"`
+    BatteryLife float64 `json:"batteryLife"` // Battery of the device after the assignment
+    Duplicate   bool    `json:"duplicate,omitempty"` // The request was already offloaded, the battery is the current one
+}
+
+// ContractError is an error with a code, its message is the JSON payload {"code": ..., "message": ...}
+type ContractError struct {
+    Code    string `json:"code"`
+    Message string `json:"message"`
+}
+
+func (e *ContractError) Error() string {
+    payload, _ := json.Marshal(e)
+    return string(payload)
+}
+
+// newContractError formats the message of an error with a code
+func newContractError(code string, format string, args ...interface{}) error {
+    return &ContractError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+
+// DeviceSpec is the device description given to RegisterDeviceJSON, the counters are filled by the contract
+type DeviceSpec struct {
+    DeviceID          string         `json:"deviceID"`
+    DeviceType        string         `json:"deviceType"`
+    BatteryLife       float64        `json:"batteryLife"`
+    ComputeResources  float64        `json:"computeResources"`
+    Profile           *DeviceProfile `json:"profile,omitempty"` // Optional, default to the current battery and resources
+}
+
+// DeviceProfile holds the optional initial values of a registered device
+type DeviceProfile struct {
+    InitialBattery    float64  `json:"initialBattery,omitempty"`
+    InitialResources  float64  `json:"initialResources,omitempty"`
+    Reputation        *float64 `json:"reputation,omitempty"`
+}
+
+
+// InitLedger initializes the ledger with some sample devices
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+    devices := []Device{
+        {DeviceID: "0001", DeviceType: "EC",  Status: "Available", BatteryLife: 100, InitialBattery: 100, ComputeResources: 100, InitialResources: 100, TasksCompleted: 0, TotalTasks: 0, TimeTasks: 0, ComputeCostDevice: 0, Reputation: 0, PreviousReputation: 0},
+        {DeviceID: "0002", DeviceType: "UAV", Status: "Available", BatteryLife: 100, InitialBattery: 100, ComputeResources: 10, InitialResources: 10, TasksCompleted: 0, TotalTasks: 0, TimeTasks: 0, ComputeCostDevice: 0, Reputation: 0, PreviousReputation: 0},
+        {DeviceID: "0003", DeviceType: "EC",  Status: "Available", BatteryLife: 100, InitialBattery: 100, ComputeResources: 100, InitialResources: 100, TasksCompleted: 0, TotalTasks: 0, TimeTasks: 0, ComputeCostDevice: 0, Reputation: 0, PreviousReputation: 0},
+        {DeviceID: "0004", DeviceType: "UAV", Status: "Available", BatteryLife: 100, InitialBattery: 100, ComputeResources: 10, InitialResources: 10, TasksCompleted: 0, TotalTasks: 0, TimeTasks: 0, ComputeCostDevice: 0, Reputation: 0, PreviousReputation: 0},
+    }
+
+    for _, device := range devices {
+        deviceAsBytes, _ := json.Marshal(device)
+        err := ctx.GetStub().PutState("D"+device.DeviceID, deviceAsBytes)
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Section 3 : Task Offload algorithm                                                          // 
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//      The offload models are pure functions of the scheduler package over the devices read   //
+//      in the ledger, their State is read and written in the ledger by each offload           //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TaskOffloadFirstAvailable assigns a task to the first available device (Test function)
+func (s *SmartContract) TaskOffloadFirstAvailable(ctx contractapi.TransactionContextInterface, taskData string, taskType string, energyCost float64, computeCost float64, requestID string) (*Assignment, error) {
+    return s.idempotent(ctx, requestID, func() (*Assignment, error) {
+        devices, _, err := s.candidates(ctx, taskType, computeCost)
+        if err != nil {
+            return nil, err
+        }
+
+        selectedDevice := new(scheduler.State).FirstAvailable(devices, computeCost)
+        return s.assignTask(ctx, selectedDevice, taskData, taskType, energyCost, computeCost, txRandom(ctx))
+    })
+}
+
+// TaskOffloadingRoundRobin assigns tasks to devices in a round-robin fashion
+func (s *SmartContract) TaskOffloadingRoundRobin(ctx contractapi.TransactionContextInterface, taskData string, taskType string, energyCost float64, computeCost float64, requestID string) (*Assignment, error) {
+    return s.idempotent(ctx, requestID, func() (*Assignment, error) {
+        devices, _, err := s.candidates(ctx, taskType, computeCost)
+        if err != nil {
+            return nil, err
+        }
+
+        return withState(ctx, func(state *scheduler.State) (*Assignment, error) {
+            selectedDevice := state.RoundRobin(devices, computeCost)
+            return s.assignTask(ctx, selectedDevice, taskData, taskType, energyCost, computeCost, txRandom(ctx))
+        })
+    })
+}
+
+// TaskOffloadRandom assigns a task to a randomly chosen device
+func (s *SmartContract) TaskOffloadRandom(ctx contractapi.TransactionContextInterface, taskData string, taskType string, energyCost float64, computeCost float64, requestID string) (*Assignment, error) {
+    return s.idempotent(ctx, requestID, func() (*Assignment, error) {
+        devices, _, err := s.candidates(ctx, taskType, computeCost)
+        if err != nil {
+            return nil, err
+        }
+
+        rnd := txRandom(ctx)
+        selectedDevice := new(scheduler.State).Random(devices, computeCost, rnd)
+        return s.assignTask(ctx, selectedDevice, taskData, taskType, energyCost, computeCost, rnd)
+    })
+}
+
+// TaskOffloadECP (Edge Server Prioritize) assigns a task first to a random EC, but not consecutively, 
+// and if all ECs have completed their tasks, UAVs will handle three times the number of tasks as ECs.
+func (s *SmartContract) TaskOffloadECP(ctx contractapi.TransactionContextInterface, taskData string, taskType string, energyCost float64, computeCost float64, requestID string) (*Assignment, error) {
+    return s.idempotent(ctx, requestID, func() (*Assignment, error) {
+        devices, _, err := s.candidates(ctx, taskType, computeCost)
+        if err != nil {
+            return nil, err
+        }
+
+        return withState(ctx, func(state *scheduler.State) (*Assignment, error) {
+            rnd := txRandom(ctx)
+            selectedDevice := state.ECP(devices, computeCost, rnd)
+            return s.assignTask(ctx, selectedDevice, taskData, taskType, energyCost, computeCost, rnd)
+        })
+    })
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Task Offload based on a Energy-Aware Task Scheduling proposed by Ningning Wang              //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//      The paper Energy-Efficient Task Scheduling in Mobile Edge Computing Systems, focus on  //
+//      optimizing task scheduling based on the energy levels of devices. Their approach aims  //  
+//      extend the network's operational time by ensuring that tasks are offloaded to devices  //  
+//      remaining energy. The function TaskOffloadEnergyAware follows the same principle by    //
+//      balancing with sufficient battery life and compute resources through an energy         //
+//      efficiency score. This scheduling technique ensures that tasks are assigned not only   //
+//      based on available computational power but also by considering the energy reserves     //
+//      of UAVs.                                                                               //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TaskOffloadEnergyAware assigns a task to ECs first. Once all ECs have completed tasks, it switches to UAVs based on energy efficiency.
+func (s *SmartContract) TaskOffloadEnergyAware(ctx contractapi.TransactionContextInterface, taskData string, taskType string, energyCost float64, computeCost float64, requestID string) (*Assignment, error) {
+    return s.idempotent(ctx, requestID, func() (*Assignment, error) {
+        devices, now, err := s.candidates(ctx, taskType, computeCost)
+        if err != nil {
+            return nil, err
+        }
+
+        return withState(ctx, func(state *scheduler.State) (*Assignment, error) {
+            rnd := txRandom(ctx)
+            selectedDevice := state.EnergyAware(devices, taskType, computeCost, now, rnd)
+            return s.assignTask(ctx, selectedDevice, taskData, taskType, energyCost, computeCost, rnd)
+        })
+    })
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Task Offload based on COBRA Framework                                                       //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//      CoBRA framework use TCI and RI to choose for each task the best Devices based          //
+//      on the porperties of the task                                                          //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TaskOffloadCobra assigns tasks using the COBRA algorithm based on RI and TCI and Reputation
+func (s *SmartContract) TaskOffloadCobra(ctx contractapi.TransactionContextInterface, taskData string, taskType string, energyCost float64, computeCost float64, lambda float64, epsilon float64, requestID string) (*Assignment, error) {
+    return s.idempotent(ctx, requestID, func() (*Assignment, error) {
+        devices, now, err := s.candidates(ctx, taskType, computeCost)
+        if err != nil {
+            return nil, err
+        }
+
+        return withState(ctx, func(state *scheduler.State) (*Assignment, error) {
+            selectedDevice := state.Cobra(devices, energyCost, computeCost, lambda, epsilon, now)
+            if selectedDevice.DeviceID == "" {
+                return nil, newContractError(ErrNoCandidate, "No available devices with sufficient ressources")
+            }
+
+            task, err := s.startTask(ctx, &selectedDevice, taskData, taskType, energyCost, computeCost, true, txRandom(ctx))
+            if err != nil {
+                return nil, err
+            }
+
+            // Recalculate reputation every 5 tasks
+            scheduler.UpdateReputation(&selectedDevice, lambda)
+            err = s.putDevice(ctx, selectedDevice)
+            if err != nil {
+                return nil, err
+            }
+            return newAssignment(task, selectedDevice), nil
+        })
+    })
+}
+
+// Utility Functions
+// idempotent offloads the task of a request only once: the assignment of a request ID already offloaded is
+// returned without assigning the task again, the request of a client retrying after a timeout whose transaction
+// was committed. Without request ID the task is always offloaded
+func (s *SmartContract) idempotent(ctx contractapi.TransactionContextInterface, requestID string, offload func() (*Assignment, error)) (*Assignment, error) {
+    if requestID == "" {
+        return offload()
+    }
+
+    taskID, err := ctx.GetStub().GetState("R" + requestID)
+    if err != nil {
+        return nil, err
+    }
+    if taskID != nil {
+        return s.previousAssignment(ctx, string(taskID))
+    }
+
+    assignment, err := offload()
+    if err != nil {
+        return nil, err
+    }
+    return assignment, ctx.GetStub().PutState("R"+requestID, []byte(assignment.TaskID))
+}
+
+// withState runs an offload model with the State read in the world state and writes the State back in the same
+// transaction when the model changed it, so all the peers select with the State of the committed tasks whatever
+// the transactions they endorsed before or their restarts. First Available and Random do not read it, so their
+// transactions do not conflict on the key
+func withState(ctx contractapi.TransactionContextInterface, offload func(state *scheduler.State) (*Assignment, error)) (*Assignment, error) {
+    var state scheduler.State
+    stateAsBytes, err := ctx.GetStub().GetState(StateKey)
+    if err != nil {
+        return nil, err
+    }
+    if stateAsBytes != nil {
+        err = json.Unmarshal(stateAsBytes, &state)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    previous := state
+    assignment, err := offload(&state)
+    if err != nil || state == previous {
+        return assignment, err
+    }
+    stateAsBytes, err = json.Marshal(state)
+    if err != nil {
+        return nil, err
+    }
+    return assignment, ctx.GetStub().PutState(StateKey, stateAsBytes)
+}
+
+// previousAssignment is the assignment of a task already offloaded with the current state of its device
+func (s *SmartContract) previousAssignment(ctx contractapi.TransactionContextInterface, taskID string) (*Assignment, error) {
+    taskAsBytes, err := ctx.GetStub().GetState("T" + taskID)
+    if err != nil {
+        return nil, err
+    }
+    if taskAsBytes == nil {
+        return nil, newContractError(ErrConflict, "Task %s of the request was deleted", taskID)
+    }
+    var task Task
+    err = json.Unmarshal(taskAsBytes, &task)
+    if err != nil {
+        return nil, err
+    }
+
+    // The device may have been deleted since the assignment, only its ID is known
+    device := Device{DeviceID: task.DeviceID}
+    deviceAsBytes, err := ctx.GetStub().GetState("D" + task.DeviceID)
+    if err != nil {
+        return nil, err
+    }
+    if deviceAsBytes != nil {
+        device, err = s.getDevice(ctx, task.DeviceID)
+        if err != nil {
+            return nil, err
+        }
+    }
+    assignment := newAssignment(task, device)
+    assignment.Duplicate = true
+    return assignment, nil
+}
+
+// candidates validates the task type and returns the devices that can take the task with the time of the transaction
+func (s *SmartContract) candidates(ctx contractapi.TransactionContextInterface, taskType string, computeCost float64) ([]Device, time.Time, error) {
+    err := validateTaskType(taskType)
+    if err != nil {
+        return nil, time.Time{}, err
+    }
+
+    devices, err := s.getAvailableDevices(ctx, computeCost)
+    if err != nil {
+        return nil, time.Time{}, err
+    }
+
+    now, err := getTxTime(ctx)
+    return devices, now, err
+}
+
+// assignTask handles the task assignment and device updates for all normal model
+func (s *SmartContract) assignTask(ctx contractapi.TransactionContextInterface, device Device, taskData string, taskType string, energyCost float64, computeCost float64, rnd *rand.Rand) (*Assignment, error) {
+    if device.DeviceID == "" {
+        return nil, newContractError(ErrNoCandidate, "No available devices with sufficient ressources")
+    }
+
+    task, err := s.startTask(ctx, &device, taskData, taskType, energyCost, computeCost, false, rnd)
+    if err != nil {
+        return nil, err
+    }
+
+    err = s.putDevice(ctx, device)
+    if err != nil {
+        return nil, err
+    }
+    return newAssignment(task, device), nil
+}
+
+// newAssignment is the result of the offload of the task on the device
+func newAssignment(task Task, device Device) *Assignment {
+    return &Assignment{
+        TaskID:      task.TaskID,
+        TaskType:    task.TaskType,
+        Status:      task.Status,
+        DeviceID:    device.DeviceID,
+        DeviceType:  device.DeviceType,
+        BatteryLife: device.BatteryLife,
+    }
+}
+
+// startTask reserves the compute resources of the device for the execution of the task and records the task,
+// with the execution times of the COBRA model if cobra is true, the execution time is drawn with rnd
+func (s *SmartContract) startTask(ctx contractapi.TransactionContextInterface, device *Device, taskData string, taskType string, energyCost float64, computeCost float64, cobra bool, rnd *rand.Rand) (Task, error) {
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return Task{}, err
+    }
+
+    // The TxID is the unique TaskID
+    task := Task{
+        TaskID:      ctx.GetStub().GetTxID(),
+        TaskData:    taskData,
+        TaskType:    taskType,
+        EnergyCost:  energyCost,
+        ComputeCost: computeCost,
+    }
+    minExecution, maxExecution := scheduler.ExecutionRange(taskType, cobra)
+    task, err = scheduler.Assign(device, task, minExecution, maxExecution, now, rnd)
+    if err == scheduler.ErrQueueFull {
+        return task, newContractError(ErrConflict, "Device %s cannot queue the task", device.DeviceID)
+    }
+    if err != nil {
+        return task, err
+    }
+
+    taskAsBytes, err := json.Marshal(task)
+    if err != nil {
+        return task, err
+    }
+    err = ctx.GetStub().PutState("T"+task.TaskID, taskAsBytes)
+    if err != nil {
+        return task, err
+    }
+    return task, ctx.GetStub().SetEvent(EventTaskAssigned, taskAsBytes)
+}
+
+// txRandom returns the source of the random draws of the transaction, seeded with the hash of its TxID so the
+// endorsing peers draw the same values and compute the same write set
+func txRandom(ctx contractapi.TransactionContextInterface) *rand.Rand {
+    hash := fnv.New64a()
+    hash.Write([]byte(ctx.GetStub().GetTxID()))
+    return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
+
+// getAvailableDevices retrieves all visible devices that can start the task or queue it, a saturated (Busy) device
+// is kept while its queue is not full. It fails with NO_CANDIDATE or INSUFFICIENT_BATTERY if there is no device
+func (s *SmartContract) getAvailableDevices(ctx contractapi.TransactionContextInterface, computeCost float64) ([]Device, error) {
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    startKey := "D0001"
+    endKey := "D:" // All the device keys, ':' follows '9' so D9999 is in the range
+
+    resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+    if err != nil {
+        return nil, err
+    }
+    defer resultsIterator.Close()
+
+    var devices []Device
+    lowBattery := 0 // Devices only excluded because their battery is too low
+    for resultsIterator.HasNext() {
+        queryResponse, err := resultsIterator.Next()
+        if err != nil {
+            return nil, err
+        }
+
+        var device Device
+        err = json.Unmarshal(queryResponse.Value, &device)
+        if err != nil {
+            return nil, err
+        }
+
+        class, err := scheduler.GetClass(device.DeviceType)
+        if err != nil {
+            continue // Devices of an unknown type are never selected
+        }
+        if class.OnBattery() && device.Status == "Unavailable" && device.BatteryLife < class.MinBattery {
+            lowBattery++
+            continue
+        }
+
+        // Resources of the tasks ended before this transaction are available again
+        scheduler.ReleaseExpired(&device, now)
+
+        if class.Eligible(device, computeCost, now) {
+            devices = append(devices, device)
+        }
+    }
+
+    if len(devices) == 0 && lowBattery > 0 {
+        return nil, newContractError(ErrInsufficientBattery, "No available devices, %d devices have an insufficient battery", lowBattery)
+    }
+    if len(devices) == 0 {
+        return nil, newContractError(ErrNoCandidate, "No available devices with sufficient ressources")
+    }
+    return devices, nil
+}
+
+// validateTaskType checks that the task type is one of the 6G use cases known by the contract
+func validateTaskType(taskType string) error {
+    if !scheduler.ValidTaskType(taskType) {
+        return newContractError(ErrUnknownTaskType, "Unknown task type %s", taskType)
+    }
+    return nil
+}
+
+// getTxTime returns the timestamp of the transaction, the same on all the peers
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+    timestamp, err := ctx.GetStub().GetTxTimestamp()
+    if err != nil {
+        return time.Time{}, err
+    }
+    return time.Unix(timestamp.GetSeconds(), int64(timestamp.GetNanos())), nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Section 3 : Other function for manage of the ledger and result                              //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// QueryAllDevices gets all devices from the world state, with the resources available at the time of the query
+func (s *SmartContract) QueryAllDevices(ctx contractapi.TransactionContextInterface) ([]Device, error) {
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    startKey := "D0001"
+    endKey := "D:" // All the device keys, ':' follows '9' so D9999 is in the range
+
+    resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+    if err != nil {
+        return nil, err
+    }
+    defer resultsIterator.Close()
+
+    var devices []Device
+    for resultsIterator.HasNext() {
+        queryResponse, err := resultsIterator.Next()
+        if err != nil {
+            return nil, err
+        }
+
+        var device Device
+        err = json.Unmarshal(queryResponse.Value, &device)
+        if err != nil {
+            return nil, err
+        }
+
+        scheduler.ReleaseExpired(&device, now)
+        devices = append(devices, device)
+    }
+
+    return devices, nil
+}
+
+// QueryAllTasks gets all tasks from the world state, a running task whose lease expired is shown as completed
+// and a queued task with the state it has in the queue of its device
+func (s *SmartContract) QueryAllTasks(ctx contractapi.TransactionContextInterface) ([]Task, error) {
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    startKey := "T"
+    endKey := "U" // All the task keys, the TxIDs are hexadecimal
+
+    resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+    if err != nil {
+        return nil, err
+    }
+    defer resultsIterator.Close()
+
+    var tasks []Task
+    devices := make(map[string]Device) // Devices of the queued tasks already read
+    for resultsIterator.HasNext() {
+        queryResponse, err := resultsIterator.Next()
+        if err != nil {
+            return nil, err
+        }
+
+        var task Task
+        err = json.Unmarshal(queryResponse.Value, &task)
+        if err != nil {
+            return nil, err
+        }
+
+        if task.Status == "Queued" {
+            device, ok := devices[task.DeviceID]
+            if !ok {
+                device, err = s.getDevice(ctx, task.DeviceID)
+                if err != nil {
+                    return nil, err
+                }
+                devices[task.DeviceID] = device
+            }
+            task.Status, task.LeaseExpiry = scheduler.TaskState(device, task.TaskID)
+        }
+        if task.Status == "Running" && task.LeaseExpiry <= now.UnixMilli() {
+            task.Status = "Completed"
+        }
+        tasks = append(tasks, task)
+    }
+
+    return tasks, nil
+}
+
+// QueryQueues gets the running and queued tasks of all devices with the expected wait of a new task
+func (s *SmartContract) QueryQueues(ctx contractapi.TransactionContextInterface) ([]QueueStatus, error) {
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    devices, err := s.QueryAllDevices(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    var queues []QueueStatus
+    for _, device := range devices {
+        class, err := scheduler.GetClass(device.DeviceType)
+        if err != nil {
+            continue
+        }
+
+        queue := QueueStatus{
+            DeviceID:     device.DeviceID,
+            DeviceType:   device.DeviceType,
+            Running:      len(device.Reservations),
+            Queued:       []string{},
+            Capacity:     class.QueueCapacity,
+            ExpectedWait: scheduler.ExpectedWait(device, 0, now),
+        }
+        for _, queued := range device.Queue {
+            queue.Queued = append(queue.Queued, queued.TaskID)
+        }
+        queues = append(queues, queue)
+    }
+
+    return queues, nil
+}
+
+// GetNetworkStats computes on the peer the aggregates of all the devices, so the clients do not have to download the fleet
+func (s *SmartContract) GetNetworkStats(ctx contractapi.TransactionContextInterface) (NetworkStats, error) {
+    devices, err := s.QueryAllDevices(ctx)
+    if err != nil {
+        return scheduler.Stats(nil), err
+    }
+    return scheduler.Stats(devices), nil
+}
+
+// RegisterDevice registers UAVs or Edge Servers in the blockchain network, taskLimit is not used anymore
+// since the compute resources are released when the tasks end
+func (s *SmartContract) RegisterDevice(ctx contractapi.TransactionContextInterface, deviceID string, deviceType string, status string, batteryLife float64, initialBattery float64,  computeResources float64, initialResources float64, tasksCompleted int, totalTasks int, timeTasks int, computeCostDevice float64, taskLimit int, reputation float64, previousreputation float64  ) error {
+    device := Device{
+        DeviceID:         deviceID,
+        DeviceType:       deviceType,
+        Status:           status,
+        BatteryLife:      batteryLife,
+        InitialBattery:   initialBattery,
+        ComputeResources: computeResources,
+        InitialResources: initialResources,
+        TasksCompleted:   tasksCompleted,
+        TotalTasks:       totalTasks,
+        TimeTasks:        timeTasks,
+        ComputeCostDevice: computeCostDevice,
+        Reputation:        reputation,
+        PreviousReputation: previousreputation,
+    }
+
+    _, err := scheduler.GetClass(deviceType)
+    if err != nil {
+        return err
+    }
+
+    err = validateDeviceCapacity(device.BatteryLife, device.InitialBattery, device.ComputeResources, device.InitialResources)
+    if err != nil {
+        return err
+    }
+
+    deviceAsBytes, err := json.Marshal(device)
+    if err != nil {
+        return err
+    }
+
+    err = ctx.GetStub().PutState("D"+deviceID, deviceAsBytes)
+    if err != nil {
+        return err
+    }
+
+    return nil
+}
+
+// RegisterDeviceJSON registers a device from a JSON DeviceSpec, an existing device is only replaced if overwrite is true
+func (s *SmartContract) RegisterDeviceJSON(ctx contractapi.TransactionContextInterface, deviceSpec string, overwrite bool) error {
+    var spec DeviceSpec
+    err := json.Unmarshal([]byte(deviceSpec), &spec)
+    if err != nil {
+        return fmt.Errorf("Invalid device spec: %v", err)
+    }
+
+    // Device IDs are 4 digits, the queries read the keys D0001 to D9999
+    if len(spec.DeviceID) != 4 || strings.Trim(spec.DeviceID, "0123456789") != "" || spec.DeviceID == "0000" {
+        return fmt.Errorf("Invalid device ID %s, must be between 0001 and 9999", spec.DeviceID)
+    }
+    class, err := scheduler.GetClass(spec.DeviceType)
+    if err != nil {
+        return err
+    }
+
+    // Default values of the class and of the profile
+    if spec.BatteryLife == 0 {
+        spec.BatteryLife = class.DefaultBattery
+    }
+    if spec.ComputeResources == 0 {
+        spec.ComputeResources = class.DefaultResources
+    }
+    initialBattery := spec.BatteryLife
+    initialResources := spec.ComputeResources
+    reputation := 1.0
+    if spec.Profile != nil {
+        if spec.Profile.InitialBattery != 0 {
+            initialBattery = spec.Profile.InitialBattery
+        }
+        if spec.Profile.InitialResources != 0 {
+            initialResources = spec.Profile.InitialResources
+        }
+        if spec.Profile.Reputation != nil {
+            reputation = *spec.Profile.Reputation
+        }
+    }
+
+    err = validateDeviceCapacity(spec.BatteryLife, initialBattery, spec.ComputeResources, initialResources)
+    if err != nil {
+        return err
+    }
+    if reputation < 0 {
+        return fmt.Errorf("Invalid reputation %.2f, must be positive", reputation)
+    }
+
+    owner, err := getClientMSP(ctx)
+    if err != nil {
+        return err
+    }
+    existing, err := ctx.GetStub().GetState("D" + spec.DeviceID)
+    if err != nil {
+        return err
+    }
+    if existing != nil && !overwrite {
+        return newContractError(ErrConflict, "Device %s already exists", spec.DeviceID)
+    }
+    if existing != nil {
+        // Only the organization of the device can overwrite it
+        var previous Device
+        err = json.Unmarshal(existing, &previous)
+        if err != nil {
+            return err
+        }
+        err = checkOwner(ctx, previous)
+        if err != nil {
+            return err
+        }
+    }
+
+    device := Device{
+        DeviceID:         spec.DeviceID,
+        DeviceType:       spec.DeviceType,
+        Status:           "Available",
+        BatteryLife:      spec.BatteryLife,
+        InitialBattery:   initialBattery,
+        ComputeResources: spec.ComputeResources,
+        InitialResources: initialResources,
+        Reputation:       reputation,
+        Owner:            owner,
+    }
+    return s.putDevice(ctx, device)
+}
+
+// CompleteTask is called when a device ends a task before its lease expires, the reserved resources are released
+func (s *SmartContract) CompleteTask(ctx contractapi.TransactionContextInterface, taskID string) error {
+    taskAsBytes, err := ctx.GetStub().GetState("T" + taskID)
+    if err != nil {
+        return err
+    }
+    if taskAsBytes == nil {
+        return fmt.Errorf("Task %s does not exist", taskID)
+    }
+
+    var task Task
+    err = json.Unmarshal(taskAsBytes, &task)
+    if err != nil {
+        return err
+    }
+
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return err
+    }
+
+    // The device knows if the task is running, a queued task may have started since it was recorded
+    device, err := s.getDevice(ctx, task.DeviceID)
+    if err != nil {
+        return err
+    }
+
+    // Release the reservation of the task, the queued tasks can start with the released resources
+    if !scheduler.Complete(&device, taskID, now) {
+        return newContractError(ErrConflict, "Task %s is not running", taskID)
+    }
+
+    task.Status = "Completed"
+    taskAsBytes, err = json.Marshal(task)
+    if err != nil {
+        return err
+    }
+    err = ctx.GetStub().PutState("T"+taskID, taskAsBytes)
+    if err != nil {
+        return err
+    }
+    err = ctx.GetStub().SetEvent(EventTaskCompleted, taskAsBytes)
+    if err != nil {
+        return err
+    }
+
+    return s.putDevice(ctx, device)
+}
+
+// ReleaseExpiredTasks writes in the ledger the release of the resources of all the tasks whose lease expired
+// and the start of the queued tasks, it returns the number of tasks completed
+func (s *SmartContract) ReleaseExpiredTasks(ctx contractapi.TransactionContextInterface) (int, error) {
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return 0, err
+    }
+
+    resultsIterator, err := ctx.GetStub().GetStateByRange("D0001", "D:")
+    if err != nil {
+        return 0, err
+    }
+    defer resultsIterator.Close()
+
+    released := 0
+    for resultsIterator.HasNext() {
+        queryResponse, err := resultsIterator.Next()
+        if err != nil {
+            return 0, err
+        }
+
+        var device Device
+        err = json.Unmarshal(queryResponse.Value, &device)
+        if err != nil {
+            return 0, err
+        }
+
+        completed := device.TasksCompleted
+        scheduler.ReleaseExpired(&device, now)
+        if device.TasksCompleted == completed {
+            continue // Nothing to write for this device
+        }
+
+        released += device.TasksCompleted - completed
+        err = s.putDevice(ctx, device)
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    return released, nil
+}
+
+// UpdateDeviceStatus changes the status of a registered device (Available, Busy or Unavailable)
+func (s *SmartContract) UpdateDeviceStatus(ctx contractapi.TransactionContextInterface, deviceID string, status string) error {
+    if status != "Available" && status != "Busy" && status != "Unavailable" {
+        return fmt.Errorf("Invalid status %s, must be Available, Busy or Unavailable", status)
+    }
+
+    device, err := s.getDevice(ctx, deviceID)
+    if err != nil {
+        return err
+    }
+    err = checkOwner(ctx, device)
+    if err != nil {
+        return err
+    }
+    if device.Status == "Decommissioned" {
+        return newContractError(ErrConflict, "Device %s is decommissioned", deviceID)
+    }
+
+    // A device under the minimum battery of its class stays Unavailable until its battery is updated
+    class, err := scheduler.GetClass(device.DeviceType)
+    if err != nil {
+        return err
+    }
+    if status == "Available" && class.OnBattery() && device.BatteryLife < class.MinBattery {
+        return newContractError(ErrInsufficientBattery, "Device %s has the battery %.2f, under the minimum %.2f of a %s", deviceID, device.BatteryLife, class.MinBattery, class.Name)
+    }
+
+    device.Status = status
+    return s.putDevice(ctx, device)
+}
+
+// UpdateDeviceCapacity changes the battery and compute resources of a registered device, the available
+// compute resources are the new initial resources minus the resources reserved by the running tasks
+func (s *SmartContract) UpdateDeviceCapacity(ctx contractapi.TransactionContextInterface, deviceID string, batteryLife float64, initialBattery float64, initialResources float64) error {
+    device, err := s.getDevice(ctx, deviceID)
+    if err != nil {
+        return err
+    }
+    err = checkOwner(ctx, device)
+    if err != nil {
+        return err
+    }
+    if device.Status == "Decommissioned" {
+        return newContractError(ErrConflict, "Device %s is decommissioned", deviceID)
+    }
+
+    computeResources := initialResources - scheduler.ReservedResources(device)
+    err = validateDeviceCapacity(batteryLife, initialBattery, computeResources, initialResources)
+    if err != nil {
+        return err
+    }
+
+    device.BatteryLife = batteryLife
+    device.InitialBattery = initialBattery
+    device.InitialResources = initialResources
+
+    // More resources can start queued tasks
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return err
+    }
+    scheduler.StartQueued(&device, now.UnixMilli())
+    device.ComputeResources = initialResources - scheduler.ReservedResources(device)
+    return s.putDevice(ctx, device)
+}
+
+// DeregisterDevice marks a device as Decommissioned, the device and its history stay in the ledger
+func (s *SmartContract) DeregisterDevice(ctx contractapi.TransactionContextInterface, deviceID string) error {
+    device, err := s.getDevice(ctx, deviceID)
+    if err != nil {
+        return err
+    }
+    err = checkOwner(ctx, device)
+    if err != nil {
+        return err
+    }
+    if device.Status == "Decommissioned" {
+        return newContractError(ErrConflict, "Device %s is already decommissioned", deviceID)
+    }
+
+    // Refuse if the device still has tasks that are not completed
+    if len(device.Reservations) > 0 {
+        return newContractError(ErrConflict, "Device %s still has task %s in flight", deviceID, device.Reservations[0].TaskID)
+    }
+    if len(device.Queue) > 0 {
+        return newContractError(ErrConflict, "Device %s still has task %s in its queue", deviceID, device.Queue[0].TaskID)
+    }
+
+    device.Status = "Decommissioned"
+    return s.putDevice(ctx, device)
+}
+
+// getDevice reads a device from the world state with the resources of its expired tasks released
+func (s *SmartContract) getDevice(ctx contractapi.TransactionContextInterface, deviceID string) (Device, error) {
+    var device Device
+
+    now, err := getTxTime(ctx)
+    if err != nil {
+        return device, err
+    }
+
+    deviceAsBytes, err := ctx.GetStub().GetState("D" + deviceID)
+    if err != nil {
+        return device, err
+    }
+    if deviceAsBytes == nil {
+        return device, fmt.Errorf("Device %s does not exist", deviceID)
+    }
+
+    err = json.Unmarshal(deviceAsBytes, &device)
+    if err != nil {
+        return device, err
+    }
+
+    scheduler.ReleaseExpired(&device, now)
+    return device, nil
+}
+
+// getClientMSP returns the MSP of the organization of the client that submitted the transaction
+func getClientMSP(ctx contractapi.TransactionContextInterface) (string, error) {
+    mspID, err := ctx.GetClientIdentity().GetMSPID()
+    if err != nil {
+        return "", newContractError(ErrUnauthorized, "Cannot identify the client: %v", err)
+    }
+    return mspID, nil
+}
+
+// checkOwner refuses the change of a device registered by another organization, the devices without owner can be changed by all
+func checkOwner(ctx contractapi.TransactionContextInterface, device Device) error {
+    if device.Owner == "" {
+        return nil
+    }
+    mspID, err := getClientMSP(ctx)
+    if err != nil {
+        return err
+    }
+    if mspID != device.Owner {
+        return newContractError(ErrUnauthorized, "Device %s belongs to %s, not to %s", device.DeviceID, device.Owner, mspID)
+    }
+    return nil
+}
+
+// putDevice writes a device in the world state
+func (s *SmartContract) putDevice(ctx contractapi.TransactionContextInterface, device Device) error {
+    deviceAsBytes, err := json.Marshal(device)
+    if err != nil {
+        return err
+    }
+    return ctx.GetStub().PutState("D"+device.DeviceID, deviceAsBytes)
+}
+
+// validateDeviceCapacity checks that the battery and compute resources are in their range
+func validateDeviceCapacity(batteryLife float64, initialBattery float64, computeResources float64, initialResources float64) error {
+    if initialBattery < 0 || batteryLife < 0 || batteryLife > initialBattery {
+        return fmt.Errorf("Invalid battery %.2f, must be between 0 and the initial battery %.2f", batteryLife, initialBattery)
+    }
+    if initialResources <= 0 || computeResources < 0 || computeResources > initialResources {
+        return fmt.Errorf("Invalid compute resources %.2f, must be between 0 and the initial resources %.2f", computeResources, initialResources)
+    }
+    return nil
+}
+
+
+// Delete to clean the all the ledger or just an selection of the ledger, the request IDs and the State of the
+// offload models are deleted with the tasks
+func (s *SmartContract) DeleteAll(ctx contractapi.TransactionContextInterface, deleteType string) error {
+    if deleteType == "tasks" || deleteType == "all" {
+        startKey := "T"
+        endKey := "U" // All the task keys, the TxIDs are hexadecimal
+
+        resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+        if err != nil {
+            return err
+        }
+        defer resultsIterator.Close()
+
+        for resultsIterator.HasNext() {
+            queryResponse, err := resultsIterator.Next()
+            if err != nil {
+                return err
+            }
+            err = ctx.GetStub().DelState(queryResponse.Key)
+            if err != nil {
+                return err
+            }
+        }
+
+        err = ctx.GetStub().DelState(StateKey)
+        if err != nil {
+            return err
+        }
+
+        requestsIterator, err := ctx.GetStub().GetStateByRange("R", "S")
+        if err != nil {
+            return err
+        }
+        defer requestsIterator.Close()
+
+        for requestsIterator.HasNext() {
+            queryResponse, err := requestsIterator.Next()
+            if err != nil {
+                return err
+            }
+            err = ctx.GetStub().DelState(queryResponse.Key)
+            if err != nil {
+                return err
+            }
+        }
+    }
+
+    if deleteType == "devices" || deleteType == "all" {
+        startKey := "D0001"
+        endKey := "D:"
+
+        resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+        if err != nil {
+            return err
+        }
+        defer resultsIterator.Close()
+
+        for resultsIterator.HasNext() {
+            queryResponse, err := resultsIterator.Next()
+            if err != nil {
+                return err
+            }
+            err = ctx.GetStub().DelState(queryResponse.Key)
+            if err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
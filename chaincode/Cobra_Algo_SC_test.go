@@ -0,0 +1,851 @@
+package chaincode
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "github.com/RezanOscar/COBRA/memstub"
+    "github.com/RezanOscar/COBRA/scheduler"
+)
+
+const testMSP = "Provider1MSP"
+
+// testLedger runs the transactions of the smart contract on an in-memory world state with a virtual clock
+type testLedger struct {
+    t        *testing.T
+    stub     *memstub.Stub
+    contract *SmartContract
+    now      time.Time
+    events   map[string][]byte // Events of the last committed transaction
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+    return &testLedger{
+        t:        t,
+        stub:     memstub.NewStub(),
+        contract: new(SmartContract),
+        now:      time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+    }
+}
+
+// invokeAs runs a transaction of a client of the MSP, it is committed only if it succeeds
+func (l *testLedger) invokeAs(mspID string, transaction func(ctx contractapi.TransactionContextInterface) error) error {
+    ctx := l.stub.Begin(l.now, mspID)
+    err := transaction(ctx)
+    if err != nil {
+        l.stub.Rollback()
+        return err
+    }
+    l.events = l.stub.Commit()
+    return nil
+}
+
+func (l *testLedger) invoke(transaction func(ctx contractapi.TransactionContextInterface) error) error {
+    return l.invokeAs(testMSP, transaction)
+}
+
+func (l *testLedger) mustInvoke(transaction func(ctx contractapi.TransactionContextInterface) error) {
+    l.t.Helper()
+    err := l.invoke(transaction)
+    if err != nil {
+        l.t.Fatalf("Transaction failed: %v", err)
+    }
+}
+
+// advance moves the virtual clock
+func (l *testLedger) advance(d time.Duration) {
+    l.now = l.now.Add(d)
+}
+
+func (l *testLedger) devices() map[string]Device {
+    l.t.Helper()
+    var devices []Device
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        var err error
+        devices, err = l.contract.QueryAllDevices(ctx)
+        return err
+    })
+
+    byID := make(map[string]Device)
+    for _, device := range devices {
+        byID[device.DeviceID] = device
+    }
+    return byID
+}
+
+func (l *testLedger) tasks() []Task {
+    l.t.Helper()
+    var tasks []Task
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        var err error
+        tasks, err = l.contract.QueryAllTasks(ctx)
+        return err
+    })
+    return tasks
+}
+
+// register adds a device with RegisterDeviceJSON
+func (l *testLedger) register(deviceID string, deviceType string, battery float64, resources float64, reputation float64) {
+    l.t.Helper()
+    spec := fmt.Sprintf(`{"deviceID": %q, "deviceType": %q, "batteryLife": %g, "computeResources": %g, "profile": {"reputation": %g}}`,
+        deviceID, deviceType, battery, resources, reputation)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDeviceJSON(ctx, spec, false)
+    })
+}
+
+// offload sends a task with the strategy and returns the device that got it, checked with the assignment returned
+// by the contract
+func (l *testLedger) offload(strategy string, taskType string, energyCost float64, computeCost float64) (string, error) {
+    before := l.devices()
+    var assignment *Assignment
+    err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        var err error
+        switch strategy {
+        case "FirstAvailable":
+            assignment, err = l.contract.TaskOffloadFirstAvailable(ctx, "data", taskType, energyCost, computeCost, "")
+        case "RoundRobin":
+            assignment, err = l.contract.TaskOffloadingRoundRobin(ctx, "data", taskType, energyCost, computeCost, "")
+        case "Random":
+            assignment, err = l.contract.TaskOffloadRandom(ctx, "data", taskType, energyCost, computeCost, "")
+        case "ECP":
+            assignment, err = l.contract.TaskOffloadECP(ctx, "data", taskType, energyCost, computeCost, "")
+        case "EnergyAware":
+            assignment, err = l.contract.TaskOffloadEnergyAware(ctx, "data", taskType, energyCost, computeCost, "")
+        case "Cobra":
+            assignment, err = l.contract.TaskOffloadCobra(ctx, "data", taskType, energyCost, computeCost, 0.3, 0.7, "")
+        default:
+            err = fmt.Errorf("Unknown strategy %s", strategy)
+        }
+        return err
+    })
+    if err != nil {
+        return "", err
+    }
+
+    for deviceID, device := range l.devices() {
+        if device.TotalTasks > before[deviceID].TotalTasks {
+            if assignment.DeviceID != deviceID || assignment.DeviceType != device.DeviceType || assignment.BatteryLife != device.BatteryLife || assignment.TaskType != taskType || assignment.TaskID == "" {
+                l.t.Errorf("Got the assignment %+v for the device %+v", assignment, device)
+            }
+            return deviceID, nil
+        }
+    }
+    l.t.Fatalf("No device got the task")
+    return "", nil
+}
+
+func (l *testLedger) mustOffload(strategy string, taskType string, energyCost float64, computeCost float64) string {
+    l.t.Helper()
+    deviceID, err := l.offload(strategy, taskType, energyCost, computeCost)
+    if err != nil {
+        l.t.Fatalf("%s offload failed: %v", strategy, err)
+    }
+    return deviceID
+}
+
+// errorCode returns the code of an error of the smart contract, "" for the errors without code
+func errorCode(err error) string {
+    var contractError *ContractError
+    if errors.As(err, &contractError) {
+        return contractError.Code
+    }
+    return ""
+}
+
+func TestInitLedger(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    devices := l.devices()
+    if len(devices) != 4 {
+        t.Fatalf("Got %d devices, want 4", len(devices))
+    }
+    for deviceID, deviceType := range map[string]string{"0001": "EC", "0002": "UAV", "0003": "EC", "0004": "UAV"} {
+        device := devices[deviceID]
+        if device.DeviceType != deviceType || device.Status != "Available" {
+            t.Errorf("Device %s is %s %s, want %s Available", deviceID, device.DeviceType, device.Status, deviceType)
+        }
+        if device.ComputeResources != device.InitialResources {
+            t.Errorf("Device %s has %.2f resources, want %.2f", deviceID, device.ComputeResources, device.InitialResources)
+        }
+    }
+}
+
+func TestRegisterDevice(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDevice(ctx, "0010", "UAV", "Available", 40, 50, 8, 10, 0, 0, 0, 0, 0, 1, 0)
+    })
+
+    device := l.devices()["0010"]
+    if device.BatteryLife != 40 || device.InitialBattery != 50 || device.ComputeResources != 10 || device.Reputation != 1 {
+        t.Errorf("Got device %+v", device)
+    }
+
+    // Invalid capacity and unknown type
+    err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDevice(ctx, "0011", "UAV", "Available", 60, 50, 8, 10, 0, 0, 0, 0, 0, 1, 0)
+    })
+    if err == nil {
+        t.Errorf("Battery above the initial battery accepted")
+    }
+    err = l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDevice(ctx, "0012", "Balloon", "Available", 40, 50, 8, 10, 0, 0, 0, 0, 0, 1, 0)
+    })
+    if err == nil {
+        t.Errorf("Unknown device type accepted")
+    }
+}
+
+func TestRegisterDeviceJSON(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDeviceJSON(ctx, `{"deviceID": "0020", "deviceType": "HAPS"}`, false)
+    })
+
+    // Defaults of the class
+    device := l.devices()["0020"]
+    class := scheduler.Classes["HAPS"]
+    if device.BatteryLife != class.DefaultBattery || device.ComputeResources != class.DefaultResources || device.Reputation != 1 {
+        t.Errorf("Got device %+v, want the defaults of the HAPS class", device)
+    }
+    if device.Owner != testMSP || device.Status != "Available" {
+        t.Errorf("Got owner %s and status %s", device.Owner, device.Status)
+    }
+
+    // An existing device is only replaced with overwrite, by its organization
+    spec := `{"deviceID": "0020", "deviceType": "HAPS", "batteryLife": 100}`
+    err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDeviceJSON(ctx, spec, false)
+    })
+    if errorCode(err) != ErrConflict {
+        t.Errorf("Got %v, want a CONFLICT", err)
+    }
+    err = l.invokeAs("Provider2MSP", func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDeviceJSON(ctx, spec, true)
+    })
+    if errorCode(err) != ErrUnauthorized {
+        t.Errorf("Got %v, want UNAUTHORIZED", err)
+    }
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDeviceJSON(ctx, spec, true)
+    })
+    if battery := l.devices()["0020"].BatteryLife; battery != 100 {
+        t.Errorf("Got battery %.2f after the overwrite, want 100", battery)
+    }
+
+    // The last device ID is read by the queries and can take tasks
+    l.register("9999", "EC", 50, 100, 1)
+    if _, ok := l.devices()["9999"]; !ok {
+        t.Errorf("Device 9999 missing from the devices")
+    }
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0020", "Unavailable")
+    })
+    if deviceID := l.mustOffload("FirstAvailable", "UC", 0.5, 0.9); deviceID != "9999" {
+        t.Errorf("Task assigned to %s, want 9999", deviceID)
+    }
+
+    // Invalid specs
+    for _, spec := range []string{
+        `{"deviceID": "20", "deviceType": "UAV"}`,
+        `{"deviceID": "0000", "deviceType": "UAV"}`,
+        `{"deviceID": "0021", "deviceType": "Balloon"}`,
+        `{"deviceID": "0021", "deviceType": "UAV", "profile": {"reputation": -1}}`,
+        `{"deviceID": "0021", "deviceType": "UAV", "batteryLife": 60, "profile": {"initialBattery": 50}}`,
+        `not json`,
+    } {
+        err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+            return l.contract.RegisterDeviceJSON(ctx, spec, false)
+        })
+        if err == nil {
+            t.Errorf("Spec %s accepted", spec)
+        }
+    }
+}
+
+func TestTaskOffloadFirstAvailable(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    deviceID := l.mustOffload("FirstAvailable", "MC", 0.9, 1.4)
+    if deviceID != "0001" {
+        t.Fatalf("Task assigned to %s, want 0001", deviceID)
+    }
+
+    device := l.devices()["0001"]
+    if device.ComputeResources != 100-1.4 || device.ComputeCostDevice != 1.4 || device.TotalTasks != 1 {
+        t.Errorf("Got device %+v", device)
+    }
+    if device.EnergyConsumed <= 0 || device.BatteryLife != 100 {
+        t.Errorf("EC consumed %.2f J and has %.2f battery, want energy and no battery drain", device.EnergyConsumed, device.BatteryLife)
+    }
+
+    tasks := l.tasks()
+    if len(tasks) != 1 || tasks[0].DeviceID != "0001" || tasks[0].Status != "Running" || tasks[0].TaskType != "MC" {
+        t.Errorf("Got tasks %+v", tasks)
+    }
+}
+
+func TestTaskOffloadingRoundRobin(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    for _, want := range []string{"0002", "0003", "0004", "0001", "0002"} {
+        if deviceID := l.mustOffload("RoundRobin", "UC", 0.5, 0.9); deviceID != want {
+            t.Errorf("Task assigned to %s, want %s", deviceID, want)
+        }
+    }
+}
+
+func TestTaskOffloadRandom(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    for i := 0; i < 8; i++ {
+        l.mustOffload("Random", "UC", 0.5, 0.9)
+    }
+
+    total := 0
+    for _, device := range l.devices() {
+        total += device.TotalTasks
+    }
+    if total != 8 {
+        t.Errorf("Got %d tasks on the devices, want 8", total)
+    }
+}
+
+func TestTaskOffloadECP(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    // One task on each EC, never the same EC twice in a row
+    first := l.mustOffload("ECP", "ISC", 1.2, 2.0)
+    second := l.mustOffload("ECP", "ISC", 1.2, 2.0)
+    if scheduler.Classes[l.devices()[first].DeviceType].Infrastructure == false || first == second || l.devices()[second].DeviceType != "EC" {
+        t.Fatalf("First tasks assigned to %s and %s, want the 2 ECs", first, second)
+    }
+
+    // Then the UAVs get 3 tasks per EC
+    for i := 0; i < 6; i++ {
+        deviceID := l.mustOffload("ECP", "ISC", 1.2, 2.0)
+        if l.devices()[deviceID].DeviceType != "UAV" {
+            t.Fatalf("Task %d of the UAV phase assigned to %s", i, deviceID)
+        }
+    }
+}
+
+func TestTaskOffloadEnergyAware(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0001", "EC", 0, 0, 1)
+    l.register("0002", "UAV", 20, 10, 1)
+    l.register("0003", "UAV", 45, 10, 1)
+
+    if deviceID := l.mustOffload("EnergyAware", "HRLLC", 1.1, 1.9); deviceID != "0001" {
+        t.Fatalf("First task assigned to %s, want the EC", deviceID)
+    }
+
+    // The UAV with the most battery has the best energy efficiency score
+    if deviceID := l.mustOffload("EnergyAware", "HRLLC", 1.1, 1.9); deviceID != "0003" {
+        t.Errorf("Task assigned to %s, want the UAV 0003 with the most battery", deviceID)
+    }
+    uav := l.devices()["0003"]
+    if uav.BatteryLife >= 45 || uav.EnergyConsumed <= 0 {
+        t.Errorf("UAV battery %.2f and energy %.2f J, want a battery drain", uav.BatteryLife, uav.EnergyConsumed)
+    }
+}
+
+func TestTaskOffloadCobra(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0001", "EC", 0, 0, 1)
+    l.register("0002", "UAV", 50, 10, 0.5)
+    l.register("0003", "UAV", 50, 10, 1.5)
+
+    // A low TCI task goes to the UAV with the best RI, a high TCI task to an EC
+    if deviceID := l.mustOffload("Cobra", "UC", 0.5, 0.9); deviceID != "0003" {
+        t.Errorf("Low TCI task assigned to %s, want the UAV 0003 with the best reputation", deviceID)
+    }
+    if deviceID := l.mustOffload("Cobra", "AIC", 2.7, 3.0); deviceID != "0001" {
+        t.Errorf("High TCI task assigned to %s, want the EC", deviceID)
+    }
+}
+
+func TestCobraReputation(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0002", "UAV", 50, 10, 1)
+
+    // The reputation is recalculated every 5 tasks with the finished tasks
+    for i := 0; i < 5; i++ {
+        if i > 0 {
+            l.advance(5 * time.Second)
+        }
+        l.mustOffload("Cobra", "UC", 0.5, 0.9)
+    }
+
+    // The 4 first tasks are finished when the fifth is assigned
+    device := l.devices()["0002"]
+    want := 0.3*(4.0/4+float64(device.TimeTasks)/4) + 0.7*1
+    if device.PreviousReputation != 1 || device.TasksCompleted != 4 {
+        t.Errorf("Got previous reputation %.2f and %d completed tasks, want 1 and 4", device.PreviousReputation, device.TasksCompleted)
+    }
+    if device.Reputation < want-1e-9 || device.Reputation > want+1e-9 {
+        t.Errorf("Got reputation %.4f, want %.4f", device.Reputation, want)
+    }
+}
+
+func TestLeaseRelease(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0002", "UAV", 50, 10, 1)
+
+    for i := 0; i < 3; i++ {
+        l.mustOffload("FirstAvailable", "MC", 0.9, 3)
+    }
+    device := l.devices()["0002"]
+    if device.Status != "Busy" || device.ComputeResources != 1 || len(device.Reservations) != 3 {
+        t.Fatalf("Got device %+v, want Busy with 1 resource left", device)
+    }
+
+    // The resources are released when the leases expire
+    l.advance(5 * time.Second)
+    device = l.devices()["0002"]
+    if device.Status != "Available" || device.ComputeResources != 10 || device.TasksCompleted != 3 || device.TimeTasks > 3 {
+        t.Errorf("Got device %+v, want Available with the 3 tasks completed", device)
+    }
+    for _, task := range l.tasks() {
+        if task.Status != "Completed" {
+            t.Errorf("Task %s is %s, want Completed", task.TaskID, task.Status)
+        }
+    }
+
+    // The release is only written by a transaction
+    var released int
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        var err error
+        released, err = l.contract.ReleaseExpiredTasks(ctx)
+        return err
+    })
+    var stored Device
+    deviceAsBytes, _ := l.stub.GetState("D0002")
+    json.Unmarshal(deviceAsBytes, &stored)
+    if released != 3 || len(stored.Reservations) != 0 || stored.TasksCompleted != 3 {
+        t.Errorf("Released %d tasks, stored device %+v", released, stored)
+    }
+}
+
+func TestQueue(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0002", "UAV", 50, 10, 1)
+
+    l.mustOffload("FirstAvailable", "MC", 0.9, 4)
+    l.mustOffload("FirstAvailable", "MC", 0.9, 4)
+
+    // The device is saturated, the next tasks wait in its queue until it is full
+    for i := 0; i < scheduler.Classes["UAV"].QueueCapacity; i++ {
+        l.mustOffload("FirstAvailable", "MC", 0.9, 4)
+    }
+    _, err := l.offload("FirstAvailable", "MC", 0.9, 4)
+    if errorCode(err) != ErrNoCandidate {
+        t.Fatalf("Got %v, want NO_CANDIDATE with a full queue", err)
+    }
+
+    var queues []QueueStatus
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        var err error
+        queues, err = l.contract.QueryQueues(ctx)
+        return err
+    })
+    if len(queues) != 1 || queues[0].Running != 2 || len(queues[0].Queued) != 3 || queues[0].ExpectedWait <= 0 {
+        t.Fatalf("Got queues %+v", queues)
+    }
+
+    queued := 0
+    for _, task := range l.tasks() {
+        if task.Status == "Queued" {
+            queued++
+        }
+    }
+    if queued != 3 {
+        t.Errorf("Got %d queued tasks, want 3", queued)
+    }
+
+    // The queued tasks start when the running tasks end
+    l.advance(time.Second + 100*time.Millisecond)
+    device := l.devices()["0002"]
+    if device.TasksCompleted != 2 || len(device.Reservations) != 2 || len(device.Queue) != 1 {
+        t.Errorf("Got %d completed, %d running and %d queued tasks, want 2, 2 and 1", device.TasksCompleted, len(device.Reservations), len(device.Queue))
+    }
+    l.advance(10 * time.Second)
+    device = l.devices()["0002"]
+    if device.TasksCompleted != 5 || device.ComputeResources != 10 || len(device.Queue) != 0 {
+        t.Errorf("Got device %+v, want the 5 tasks completed", device)
+    }
+}
+
+func TestCompleteTask(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0002", "UAV", 50, 10, 1)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        _, err := l.contract.TaskOffloadFirstAvailable(ctx, "data", "AIC", 2.7, 3.0, "")
+        return err
+    })
+    var assigned Task
+    json.Unmarshal(l.events[EventTaskAssigned], &assigned)
+    taskID := l.tasks()[0].TaskID
+    if assigned.TaskID != taskID || assigned.DeviceID != "0002" || assigned.Status != "Running" {
+        t.Errorf("Got the events %v of the assignment", l.events)
+    }
+
+    l.advance(100 * time.Millisecond)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.CompleteTask(ctx, taskID)
+    })
+    var completed Task
+    json.Unmarshal(l.events[EventTaskCompleted], &completed)
+    if len(l.events) != 1 || completed.TaskID != taskID || completed.Status != "Completed" {
+        t.Errorf("Got the events %v of the completion", l.events)
+    }
+    device := l.devices()["0002"]
+    if device.ComputeResources != 10 || device.TasksCompleted != 1 || device.TimeTasks != 1 || l.tasks()[0].Status != "Completed" {
+        t.Errorf("Got device %+v after the completion", device)
+    }
+
+    err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.CompleteTask(ctx, taskID)
+    })
+    if errorCode(err) != ErrConflict {
+        t.Errorf("Got %v, want a CONFLICT for a completed task", err)
+    }
+    err = l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.CompleteTask(ctx, "unknown")
+    })
+    if err == nil {
+        t.Errorf("Unknown task completed")
+    }
+}
+
+func TestDeviceUpdates(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0002", "UAV", 50, 10, 1)
+
+    err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0002", "Sleeping")
+    })
+    if err == nil {
+        t.Errorf("Invalid status accepted")
+    }
+    err = l.invokeAs("Provider2MSP", func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0002", "Unavailable")
+    })
+    if errorCode(err) != ErrUnauthorized {
+        t.Errorf("Got %v, want UNAUTHORIZED for another organization", err)
+    }
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0002", "Unavailable")
+    })
+    if _, err := l.offload("FirstAvailable", "UC", 0.5, 0.9); errorCode(err) != ErrNoCandidate {
+        t.Errorf("Got %v, want NO_CANDIDATE with the only device Unavailable", err)
+    }
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0002", "Available")
+    })
+
+    // The new capacity keeps the resources reserved by the running tasks
+    l.mustOffload("FirstAvailable", "UC", 0.5, 2)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceCapacity(ctx, "0002", 30, 40, 20)
+    })
+    device := l.devices()["0002"]
+    if device.BatteryLife != 30 || device.InitialBattery != 40 || device.InitialResources != 20 || device.ComputeResources != 18 {
+        t.Errorf("Got device %+v after the capacity update", device)
+    }
+    err = l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceCapacity(ctx, "0002", 50, 40, 20)
+    })
+    if err == nil {
+        t.Errorf("Battery above the initial battery accepted")
+    }
+
+    // A device is only deregistered without tasks in flight
+    err = l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.DeregisterDevice(ctx, "0002")
+    })
+    if errorCode(err) != ErrConflict {
+        t.Errorf("Got %v, want a CONFLICT with a running task", err)
+    }
+    l.advance(5 * time.Second)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.DeregisterDevice(ctx, "0002")
+    })
+    if status := l.devices()["0002"].Status; status != "Decommissioned" {
+        t.Errorf("Got status %s, want Decommissioned", status)
+    }
+    err = l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0002", "Available")
+    })
+    if errorCode(err) != ErrConflict {
+        t.Errorf("Got %v, want a CONFLICT for a decommissioned device", err)
+    }
+    if _, err := l.offload("FirstAvailable", "UC", 0.5, 0.9); errorCode(err) != ErrNoCandidate {
+        t.Errorf("Got %v, want NO_CANDIDATE with the only device decommissioned", err)
+    }
+}
+
+func TestUnknownTaskType(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    for _, strategy := range []string{"FirstAvailable", "RoundRobin", "Random", "ECP", "EnergyAware", "Cobra"} {
+        if _, err := l.offload(strategy, "XR", 1, 1); errorCode(err) != ErrUnknownTaskType {
+            t.Errorf("%s: got %v, want UNKNOWN_TASK_TYPE", strategy, err)
+        }
+    }
+    if tasks := l.tasks(); len(tasks) != 0 {
+        t.Errorf("Got %d tasks, want none", len(tasks))
+    }
+}
+
+func TestIdempotentRequest(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    offload := func(taskType string, requestID string) (*Assignment, error) {
+        var assignment *Assignment
+        err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+            var err error
+            assignment, err = l.contract.TaskOffloadCobra(ctx, "data", taskType, 0.5, 0.9, 0.3, 0.7, requestID)
+            return err
+        })
+        return assignment, err
+    }
+
+    // The request sent again after a timeout gets the first assignment, the task is not assigned twice
+    first, err := offload("UC", "req-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    before := l.devices()[first.DeviceID]
+    l.advance(time.Second)
+    second, err := offload("UC", "req-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if second.TaskID != first.TaskID || second.DeviceID != first.DeviceID || first.Duplicate || !second.Duplicate {
+        t.Errorf("Got the assignments %+v and %+v of the same request", first, second)
+    }
+    if tasks := l.tasks(); len(tasks) != 1 || l.devices()[first.DeviceID].TotalTasks != before.TotalTasks {
+        t.Errorf("Got %d tasks after the duplicate request, want 1", len(tasks))
+    }
+
+    // A rejected request is not recorded, without request ID each call is a new task
+    if _, err := offload("XR", "req-2"); errorCode(err) != ErrUnknownTaskType {
+        t.Errorf("Got %v, want UNKNOWN_TASK_TYPE", err)
+    }
+    if assignment, err := offload("UC", "req-2"); err != nil || assignment.Duplicate {
+        t.Errorf("Got %+v, %v for the request after its rejection", assignment, err)
+    }
+    offload("UC", "")
+    offload("UC", "")
+    if tasks := l.tasks(); len(tasks) != 4 {
+        t.Errorf("Got %d tasks, want 4", len(tasks))
+    }
+
+    // The assignment of a deleted device only has its ID, a device that cannot be read is an error
+    l.stub.Begin(l.now, testMSP)
+    l.stub.DelState("D" + first.DeviceID)
+    l.stub.Commit()
+    if assignment, err := offload("UC", "req-1"); err != nil || assignment.DeviceID != first.DeviceID || assignment.DeviceType != "" {
+        t.Errorf("Got %+v, %v for the request of a deleted device", assignment, err)
+    }
+    l.stub.Begin(l.now, testMSP)
+    l.stub.PutState("D"+first.DeviceID, []byte("not json"))
+    l.stub.Commit()
+    if _, err := offload("UC", "req-1"); err == nil {
+        t.Errorf("No error for a device that cannot be read")
+    }
+}
+
+func TestEndorsementHistories(t *testing.T) {
+    // Two peers endorse the same transactions with their own instance of the contract, the second one has also
+    // endorsed transactions that were not committed (evaluated or invalidated) and is restarted every 4 tasks
+    for _, strategy := range []string{"FirstAvailable", "RoundRobin", "Random", "ECP", "EnergyAware", "Cobra"} {
+        l := newTestLedger(t)
+        l.mustInvoke(l.contract.InitLedger)
+        peers := []*SmartContract{new(SmartContract), new(SmartContract)}
+
+        for i := 0; i < 12; i++ {
+            committed, txCount := l.stub.Snapshot()
+            l.contract = peers[1]
+            l.mustOffload(strategy, "UC", 0.5, 0.9)
+            if i%4 == 3 {
+                peers[1] = new(SmartContract)
+            }
+
+            var written []map[string][]byte
+            for _, peer := range peers {
+                l.stub.Restore(committed, txCount)
+                l.contract = peer
+                l.mustOffload(strategy, "UC", 0.5, 0.9)
+                state, _ := l.stub.Snapshot()
+                written = append(written, state)
+            }
+
+            for key, value := range written[0] {
+                if string(written[1][key]) != string(value) {
+                    t.Fatalf("%s task %d: the peers wrote %s and %s in %s", strategy, i, value, written[1][key], key)
+                }
+            }
+            if len(written[0]) != len(written[1]) {
+                t.Fatalf("%s task %d: the peers wrote %d and %d keys", strategy, i, len(written[0]), len(written[1]))
+            }
+        }
+    }
+}
+
+func TestSchedulerState(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+
+    // Round Robin continues after the last device of the ledger with a new instance of the contract
+    first := l.mustOffload("RoundRobin", "UC", 0.5, 0.9)
+    l.contract = new(SmartContract)
+    second := l.mustOffload("RoundRobin", "UC", 0.5, 0.9)
+    if first == second {
+        t.Errorf("Round Robin selected %s twice after the restart", first)
+    }
+
+    var state scheduler.State
+    committed, _ := l.stub.Snapshot()
+    err := json.Unmarshal(committed[StateKey], &state)
+    if err != nil {
+        t.Fatalf("Failed to read the State in the ledger: %v", err)
+    }
+    if state.LastUsedEC != second {
+        t.Errorf("Got the last used EC %s in the ledger, want %s", state.LastUsedEC, second)
+    }
+
+    // A rejected offload does not change the State
+    _, err = l.offload("RoundRobin", "XX", 0.5, 0.9)
+    if errorCode(err) != ErrUnknownTaskType {
+        t.Fatalf("Got %v, want UNKNOWN_TASK_TYPE", err)
+    }
+    after, _ := l.stub.Snapshot()
+    if string(after[StateKey]) != string(committed[StateKey]) {
+        t.Errorf("The rejected offload wrote the State %s", after[StateKey])
+    }
+}
+
+func TestInsufficientBattery(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.RegisterDeviceJSON(ctx, `{"deviceID": "0002", "deviceType": "UAV", "batteryLife": 4, "profile": {"initialBattery": 50}}`, false)
+    })
+
+    // An Immersive Communication task drains more than 1 unit of battery (hover during the transmission)
+    l.mustOffload("FirstAvailable", "IC", 2.2, 2.7)
+    if status := l.devices()["0002"].Status; status != "Unavailable" {
+        t.Fatalf("Got status %s, want Unavailable under the minimum battery", status)
+    }
+    if _, err := l.offload("FirstAvailable", "IC", 2.2, 2.7); errorCode(err) != ErrInsufficientBattery {
+        t.Errorf("Got %v, want INSUFFICIENT_BATTERY", err)
+    }
+
+    // The device cannot be made Available again before its battery is updated
+    err := l.invoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0002", "Available")
+    })
+    if errorCode(err) != ErrInsufficientBattery {
+        t.Errorf("Got %v, want INSUFFICIENT_BATTERY for a device under the minimum battery", err)
+    }
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceCapacity(ctx, "0002", 40, 50, 10)
+    })
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.UpdateDeviceStatus(ctx, "0002", "Available")
+    })
+    if status := l.devices()["0002"].Status; status != "Available" {
+        t.Errorf("Got status %s after the battery update, want Available", status)
+    }
+}
+
+func TestLEOVisibility(t *testing.T) {
+    l := newTestLedger(t)
+    l.register("0030", "LEO", 0, 0, 1)
+
+    // Find a time out of the visibility window of the satellite
+    class := scheduler.Classes["LEO"]
+    for class.IsVisible("0030", l.now) {
+        l.advance(time.Minute)
+    }
+    if _, err := l.offload("FirstAvailable", "UC", 0.5, 0.9); errorCode(err) != ErrNoCandidate {
+        t.Errorf("Got %v, want NO_CANDIDATE with the satellite out of view", err)
+    }
+
+    for !class.IsVisible("0030", l.now) {
+        l.advance(time.Minute)
+    }
+    if deviceID := l.mustOffload("FirstAvailable", "UC", 0.5, 0.9); deviceID != "0030" {
+        t.Errorf("Task assigned to %s, want the satellite", deviceID)
+    }
+}
+
+func TestGetNetworkStats(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+    for i := 0; i < 4; i++ {
+        l.mustOffload("RoundRobin", "UC", 0.5, 0.9)
+    }
+
+    var stats NetworkStats
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        var err error
+        stats, err = l.contract.GetNetworkStats(ctx)
+        return err
+    })
+
+    if stats.Devices != 4 || stats.AvailableUAVs != 2 || stats.RunningTasks != 4 {
+        t.Errorf("Got stats %+v", stats)
+    }
+    if stats.TaskShareUAV != 50 || stats.TaskShareEC != 50 || stats.AvgTasksUAV != 1 || stats.AvgTasksEC != 1 {
+        t.Errorf("Got task shares %.2f / %.2f and tasks %.2f / %.2f, want 50 / 50 and 1 / 1", stats.TaskShareUAV, stats.TaskShareEC, stats.AvgTasksUAV, stats.AvgTasksEC)
+    }
+    if stats.AvgUAVBattery >= 100 || stats.EnergyPerTaskUAV <= 0 || stats.TypeStats["EC"].Devices != 2 {
+        t.Errorf("Got stats %+v", stats)
+    }
+
+    // All the devices have the reputation 0 of InitLedger
+    if stats.Reputation.Histogram[0] != 4 || stats.Reputation.Mean != 0 {
+        t.Errorf("Got reputation %+v", stats.Reputation)
+    }
+}
+
+func TestDeleteAll(t *testing.T) {
+    l := newTestLedger(t)
+    l.mustInvoke(l.contract.InitLedger)
+    for i := 0; i < 6; i++ {
+        l.mustOffload("RoundRobin", "UC", 0.5, 0.9)
+    }
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        _, err := l.contract.TaskOffloadRandom(ctx, "data", "UC", 0.5, 0.9, "req-1")
+        return err
+    })
+
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.DeleteAll(ctx, "tasks")
+    })
+    if len(l.tasks()) != 0 || len(l.devices()) != 4 {
+        t.Errorf("Got %d tasks and %d devices, want 0 and 4", len(l.tasks()), len(l.devices()))
+    }
+
+    l.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+        return l.contract.DeleteAll(ctx, "all")
+    })
+    if keys := l.stub.Keys(); len(keys) != 0 {
+        t.Errorf("Keys left after DeleteAll: %v", keys)
+    }
+}
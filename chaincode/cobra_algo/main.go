@@ -0,0 +1,31 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Chaincode of the COBRA Smart Contract on the peers
+//
+// version : 1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - Starts the SmartContract of the chaincode package, it is the --path of the package
+//      of the chaincode
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "github.com/RezanOscar/COBRA/chaincode"
+)
+
+func main() {
+    cc, err := contractapi.NewChaincode(new(chaincode.SmartContract))
+    if err != nil {
+        panic(err)
+    }
+
+    if err := cc.Start(); err != nil {
+        panic(err)
+    }
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "io"
+    "math/rand"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/RezanOscar/COBRA/chaincode"
+    "github.com/RezanOscar/COBRA/ledger"
+    "github.com/RezanOscar/COBRA/loadgen"
+    "github.com/RezanOscar/COBRA/scenario"
+    "github.com/RezanOscar/COBRA/simulator"
+)
+
+// runCommand runs cobractl on the world state of the memory backend in statePath
+func runCommand(t *testing.T, statePath string, args ...string) error {
+    t.Helper()
+    c := newCLI()
+    c.global.SetOutput(io.Discard)
+    return c.run(append([]string{"-backend", "memory", "-state", statePath}, args...))
+}
+
+// openState opens the world state written by the commands
+func openState(t *testing.T, statePath string) ledger.LedgerClient {
+    t.Helper()
+    config := ledger.DefaultConfig()
+    config.Backend = ledger.BackendMemory
+    config.StatePath = statePath
+    client, err := ledger.New(config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return client
+}
+
+func TestDevices(t *testing.T) {
+    statePath := filepath.Join(t.TempDir(), "ledger.json")
+    err := runCommand(t, statePath, "devices", "register", "-ecs", "2", "-uavs", "3", "-leos", "1")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    client := openState(t, statePath)
+    devices, err := queryDevices(client)
+    client.Close()
+    if err != nil {
+        t.Fatal(err)
+    }
+    counts := make(map[string]int)
+    for _, device := range devices {
+        counts[device.DeviceType]++
+    }
+    if len(devices) != 6 || counts["EC"] != 2 || counts["UAV"] != 3 || counts["LEO"] != 1 {
+        t.Fatalf("Got devices %v, want 2 EC, 3 UAVs and 1 LEO", counts)
+    }
+
+    // The capacity flags that are not set keep the values of the device
+    deviceID := devices[0].DeviceID
+    err = runCommand(t, statePath, "devices", "update", "-status", "Unavailable", "-battery", "20", deviceID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    client = openState(t, statePath)
+    device, err := findDevice(client, deviceID)
+    client.Close()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if device.Status != "Unavailable" || device.BatteryLife != 20 || device.InitialBattery != devices[0].InitialBattery {
+        t.Errorf("Got device %+v", device)
+    }
+
+    err = runCommand(t, statePath, "devices", "update", "-deregister", deviceID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = runCommand(t, statePath, "devices", "update", "-status", "Available", deviceID)
+    if err == nil || !strings.Contains(err.Error(), chaincode.ErrConflict) {
+        t.Errorf("Got %v, want a CONFLICT for a decommissioned device", err)
+    }
+    for _, args := range [][]string{{"devices", "update", deviceID}, {"devices", "list", "UAV", "EC"}} {
+        if runCommand(t, statePath, args...) == nil {
+            t.Errorf("No error for %v", args)
+        }
+    }
+
+    // The device IDs of the largest fleet go up to 9999
+    specs, err := fleetSpecs(simulator.Fleet{EC: 9, UAV: 9990}, rand.New(rand.NewSource(1)))
+    if err != nil || len(specs) != simulator.MaxDevices {
+        t.Errorf("Got %d devices, %v for the largest fleet", len(specs), err)
+    }
+    if _, err := fleetSpecs(simulator.Fleet{EC: 10, UAV: 9990}, rand.New(rand.NewSource(1))); err == nil {
+        t.Errorf("No error for a fleet of 10000 devices")
+    }
+}
+
+func TestTasksAndClean(t *testing.T) {
+    statePath := filepath.Join(t.TempDir(), "ledger.json")
+    err := runCommand(t, statePath, "devices", "register")
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = runCommand(t, statePath, "tasks", "submit", "-type", "MC", "-count", "3", "-strategy", "TaskOffloadRandom")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, args := range [][]string{{"tasks", "list"}, {"devices", "queues", "waiting"}, {"stats"}} {
+        err := runCommand(t, statePath, args...)
+        if err != nil {
+            t.Errorf("%v: %v", args, err)
+        }
+    }
+
+    client := openState(t, statePath)
+    payload, _ := client.Evaluate("QueryAllTasks")
+    client.Close()
+    if count := strings.Count(string(payload), `"taskType":"MC"`); count != 3 {
+        t.Errorf("Got %d tasks of type MC, want 3", count)
+    }
+
+    err = runCommand(t, statePath, "ledger", "clean", "all")
+    if err != nil {
+        t.Fatal(err)
+    }
+    client = openState(t, statePath)
+    devices, _ := queryDevices(client)
+    client.Close()
+    if len(devices) != 0 {
+        t.Errorf("Got %d devices after the cleaning", len(devices))
+    }
+    if runCommand(t, statePath, "ledger", "clean", "everything") == nil {
+        t.Errorf("No error for an invalid clean argument")
+    }
+}
+
+func TestSimulate(t *testing.T) {
+    dir := t.TempDir()
+    statePath := filepath.Join(dir, "ledger.json")
+    err := runCommand(t, statePath, "devices", "register", "-ecs", "1", "-uavs", "4")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // The global flags select the ledger of the simulation
+    err = runCommand(t, statePath, "simulate", "-tasks", "20", "-out", dir, "-strategy", "TaskOffloadECP", "-task-log", "jsonl")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, name := range []string{"scenario_TaskOffloadECP.yaml", "graphe_result_TaskOffloadECP.csv"} {
+        if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+            t.Errorf("Missing result: %v", err)
+        }
+    }
+
+    // The task log has the assignment of each task on the ledger
+    data, err := os.ReadFile(filepath.Join(dir, "tasks_TaskOffloadECP.jsonl"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+    var record taskRecord
+    json.Unmarshal([]byte(lines[0]), &record)
+    if len(lines) != 20 || record.Strategy != "TaskOffloadECP" || record.Attempts < 1 || record.Committed.Before(record.Submitted) {
+        t.Errorf("Got %d records, the first one %+v", len(lines), record)
+    }
+    if record.Success && (record.TaskID == "" || record.DeviceID == "" || record.DeviceType == "") {
+        t.Errorf("Missing the assignment in %+v", record)
+    }
+
+    // Open loop of 4 clients at 200 tasks/s
+    err = runCommand(t, statePath, "simulate", "-tasks", "20", "-out", filepath.Join(dir, "open"), "-rate", "200", "-clients", "4")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if rows := readCSV(t, filepath.Join(dir, "open", "graphe_result_TaskOffloadCobra.csv")); len(rows) != 4 {
+        t.Errorf("Got %d rows for 20 tasks, want the header and 3 reports", len(rows))
+    }
+
+    // The statistics of the report exclude the warm-up, which must be shorter than the run
+    err = runCommand(t, statePath, "simulate", "-mode", "des", "-tasks", "50", "-out", dir, "-rate", "2", "-task-log", "csv", "-warmup", "10", "-confidence", "0.99", "-batches", "4")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if runCommand(t, statePath, "simulate", "-mode", "des", "-tasks", "50", "-out", dir, "-warmup", "50") == nil {
+        t.Errorf("No error for a warm-up of all the tasks")
+    }
+    rows := readCSV(t, filepath.Join(dir, "tasks_des_TaskOffloadCobra.csv"))
+    if len(rows) != 51 || rows[0][0] != "taskID" || rows[1][2] != "TaskOffloadCobra" || (rows[1][10] == "true" && rows[1][3] == "") {
+        t.Errorf("Got %d rows in the task log, the first ones %v", len(rows), rows[:2])
+    }
+    if _, err := os.Stat(filepath.Join(dir, "graphe_result_des_TaskOffloadCobra.csv")); err != nil {
+        t.Errorf("Missing result: %v", err)
+    }
+
+    // Charts and summary of the results and of the task log
+    err = runCommand(t, statePath, "report", "-in", dir, "-out", filepath.Join(dir, "report"), "-format", "all")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, name := range []string{"report.html", "battery.svg", "latency_cdf.png", "compare_latency.svg"} {
+        if _, err := os.Stat(filepath.Join(dir, "report", name)); err != nil {
+            t.Errorf("Missing chart: %v", err)
+        }
+    }
+    if err := runCommand(t, statePath, "report", "-in", filepath.Join(dir, "none")); err == nil {
+        t.Errorf("No error for a directory without results")
+    }
+
+    // Replay of a trace, the record of an unknown type is rejected
+    tracePath := filepath.Join(dir, "trace.jsonl")
+    os.WriteFile(tracePath, []byte(`{"timestamp": 0, "type": "UC", "energyCost": 0.5, "computeCost": 0.9}
+{"timestamp": 1, "type": "XR", "energyCost": 1, "computeCost": 1}
+{"timestamp": 3, "type": "MC", "energyCost": 0.9, "computeCost": 1.4}
+`), 0644)
+    err = runCommand(t, statePath, "simulate", "-out", filepath.Join(dir, "trace"), "-trace", tracePath, "-trace-scale", "0.01")
+    if err != nil {
+        t.Fatal(err)
+    }
+    client := openState(t, statePath)
+    payload, _ := client.Evaluate("QueryAllTasks")
+    client.Close()
+    if !strings.Contains(string(payload), `"taskType":"MC"`) || strings.Contains(string(payload), `"taskType":"XR"`) {
+        t.Errorf("The tasks of the trace are not on the ledger")
+    }
+}
+
+func TestUnknownCommand(t *testing.T) {
+    statePath := filepath.Join(t.TempDir(), "ledger.json")
+    for _, args := range [][]string{{}, {"device"}, {"devices", "delete"}} {
+        err := runCommand(t, statePath, args...)
+        if err == nil || !strings.Contains(err.Error(), "command") {
+            t.Errorf("Got %v for %v, want an error for the command", err, args)
+        }
+    }
+}
+
+func TestSweep(t *testing.T) {
+    dir := t.TempDir()
+    err := runCommand(t, filepath.Join(dir, "ledger.json"), "sweep", "-mode", "des", "-tasks", "50", "-out", dir,
+        "-vary", "lambda=0.1,0.5", "-vary", "epsilon=0.7", "-replications", "2")
+    if err != nil {
+        t.Fatal(err)
+    }
+    rows := readCSV(t, filepath.Join(dir, "sweep_results.csv"))
+    if len(rows) != 3 || rows[0][0] != "lambda" || rows[0][1] != "epsilon" || rows[1][0] != "0.1" || rows[2][0] != "0.5" || rows[1][2] != "2" {
+        t.Errorf("Got the table %v", rows)
+    }
+    if _, err := os.Stat(filepath.Join(dir, "lambda-0.5_epsilon-0.7", "seed_2", "graphe_result_des_TaskOffloadCobra.csv")); err != nil {
+        t.Errorf("Missing result of a run: %v", err)
+    }
+
+    // On the ledger the fleet is registered again before each run
+    err = runCommand(t, filepath.Join(dir, "ledger.json"), "sweep", "-tasks", "20", "-ecs", "1", "-uavs", "3", "-out", dir,
+        "-vary", "strategy=TaskOffloadRandom,TaskOffloadECP")
+    if err != nil {
+        t.Fatal(err)
+    }
+    rows = readCSV(t, filepath.Join(dir, "sweep_results.csv"))
+    if len(rows) != 3 || rows[1][0] != "TaskOffloadRandom" {
+        t.Errorf("Got the table %v", rows)
+    }
+    client := openState(t, filepath.Join(dir, "ledger.json"))
+    devices, _ := queryDevices(client)
+    client.Close()
+    if len(devices) != 4 {
+        t.Errorf("Got %d devices after the sweep, want the 4 of the fleet", len(devices))
+    }
+
+    err = runCommand(t, filepath.Join(dir, "ledger.json"), "sweep", "-vary", "seed=1,2")
+    if err == nil {
+        t.Errorf("No error for a sweep of the seed")
+    }
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+    t.Helper()
+    file, err := os.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer file.Close()
+    rows, err := csv.NewReader(file).ReadAll()
+    if err != nil {
+        t.Fatal(err)
+    }
+    return rows
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+    statePath := filepath.Join(t.TempDir(), "ledger.json")
+    c := newCLI()
+    c.global.SetOutput(io.Discard)
+    err := c.run([]string{"-backend", "memory", "-state", statePath, "-metrics", "127.0.0.1:0", "devices", "register"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = c.run([]string{"-backend", "memory", "-state", statePath, "-metrics", "127.0.0.1:0", "tasks", "submit", "-type", "MC", "-count", "2"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if c.metrics == nil {
+        t.Fatal("No metrics with -metrics")
+    }
+
+    recorder := httptest.NewRecorder()
+    c.metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+    body := recorder.Body.String()
+    for _, want := range []string{`cobra_devices_registered_total{device_type="UAV"} 27`, `cobra_tasks_submitted_total{strategy="TaskOffloadCobra",type="MC"} 2`, `cobra_tasks_succeeded_total`} {
+        if !strings.Contains(body, want) {
+            t.Errorf("Missing %s in the metrics:\n%s", want, body)
+        }
+    }
+}
+
+// flakyLedger runs the transactions on the memory backend and fails the next ones with the errors of a network:
+// "timeout" after the commit, "unseen" after the commit with a TxID that cannot be found, "lost" before it and "mvcc"
+// invalidated at the commit
+type flakyLedger struct {
+    ledger.LedgerClient
+    failures []string
+}
+
+func (f *flakyLedger) Submit(fcn string, args ...string) ([]byte, error) {
+    if len(f.failures) == 0 {
+        return f.LedgerClient.Submit(fcn, args...)
+    }
+    failure := f.failures[0]
+    f.failures = f.failures[1:]
+    switch failure {
+    case "timeout", "unseen":
+        payload, err := f.LedgerClient.Submit(fcn, args...)
+        if err != nil {
+            return nil, err
+        }
+        var assignment chaincode.Assignment
+        json.Unmarshal(payload, &assignment)
+        if failure == "unseen" {
+            assignment.TaskID = "unseen"
+        }
+        return nil, &ledger.TxError{TxID: assignment.TaskID, Err: errors.New("request timed out or been cancelled")}
+    case "lost":
+        return nil, &ledger.TxError{TxID: "lost", Err: errors.New("Timeout waiting for the commit")}
+    }
+    return nil, &ledger.TxError{TxID: "mvcc", Err: errors.New("TxValidationCode: MVCC_READ_CONFLICT")}
+}
+
+// statsLedger fails the queries of the network stats after the first ones
+type statsLedger struct {
+    ledger.LedgerClient
+    queries int // Queries answered before the failures
+}
+
+func (s *statsLedger) Evaluate(fcn string, args ...string) ([]byte, error) {
+    if fcn == "GetNetworkStats" {
+        if s.queries == 0 {
+            return nil, errors.New("connection refused")
+        }
+        s.queries--
+    }
+    return s.LedgerClient.Evaluate(fcn, args...)
+}
+
+func TestSimulateStatsFailure(t *testing.T) {
+    client := openState(t, filepath.Join(t.TempDir(), "ledger.json"))
+    defer client.Close()
+    specs, _ := fleetSpecs(simulator.Fleet{EC: 1, UAV: 4}, rand.New(rand.NewSource(1)))
+    registerDevices(client, specs, false, nil)
+
+    scn := scenario.Default()
+    scn.Tasks, scn.Clients, scn.ReportInterval, scn.ReportIntervalScreen = 20, 1, 10, 5
+    scn.Output = t.TempDir()
+
+    // The stats of the first row of the results cannot be read after 10 tasks, the simulation stops
+    _, err := runFabric(&statsLedger{LedgerClient: client, queries: 2}, scn, nil)
+    if err == nil || !strings.Contains(err.Error(), "after 10 tasks") {
+        t.Errorf("Got %v, want the failure of the stats after 10 tasks", err)
+    }
+    if _, err := os.Stat(filepath.Join(scn.Output, "graphe_result_TaskOffloadCobra.csv")); err == nil {
+        t.Errorf("Results written without the stats")
+    }
+}
+
+// countTasks is the number of tasks on the ledger
+func countTasks(t *testing.T, client ledger.LedgerClient) int {
+    t.Helper()
+    payload, err := client.Evaluate("QueryAllTasks")
+    if err != nil {
+        t.Fatal(err)
+    }
+    var tasks []chaincode.Task
+    json.Unmarshal(payload, &tasks)
+    return len(tasks)
+}
+
+func TestRetryPolicy(t *testing.T) {
+    client := openState(t, filepath.Join(t.TempDir(), "ledger.json"))
+    defer client.Close()
+    specs, _ := fleetSpecs(simulator.Fleet{EC: 1, UAV: 2}, rand.New(rand.NewSource(1)))
+    registerDevices(client, specs, false, nil)
+
+    scn := scenario.Default()
+    scn.Strategy = "TaskOffloadFirstAvailable"
+    scn.Backoff = scenario.Backoff{Base: scenario.Duration(time.Millisecond), Max: scenario.Duration(5 * time.Millisecond)}
+    task := loadgen.Task{TaskType: simulator.TaskType{Name: "UC", EnergyCost: 0.5, ComputeCost: 0.9}, TaskData: "data"}
+    for _, c := range []struct {
+        failures []string
+        taskType string
+        attempts int
+        success  bool
+        code     string
+        tasks    int // Tasks added on the ledger
+    }{
+        {[]string{"timeout"}, "UC", 1, true, "", 1},             // Committed, not sent again
+        {[]string{"lost", "mvcc"}, "UC", 3, true, "", 1},        // Not committed, sent again
+        {[]string{"unseen"}, "UC", 2, true, "", 1},              // Sent again with its request ID, not assigned twice
+        {[]string{"mvcc", "mvcc", "mvcc", "mvcc", "mvcc"}, "UC", 5, false, ledger.ErrMVCCConflict, 0},
+        {nil, "XR", 1, false, chaincode.ErrUnknownTaskType, 0}, // Business rejection, not retried
+    } {
+        before := countTasks(t, client)
+        flaky := &flakyLedger{LedgerClient: client, failures: c.failures}
+        task.TaskType.Name = c.taskType
+        result := newSender(flaky, scn, nil)(task)
+        if result.Attempts != c.attempts || result.Success != c.success || result.ErrorCode != c.code {
+            t.Errorf("%v %s: got %d attempts, success %t, code %s", c.failures, c.taskType, result.Attempts, result.Success, result.ErrorCode)
+        }
+        if added := countTasks(t, client) - before; added != c.tasks {
+            t.Errorf("%v %s: got %d tasks added, want %d", c.failures, c.taskType, added, c.tasks)
+        }
+        if c.success && (result.TaskID == "" || result.DeviceID == "" || result.DeviceType == "") {
+            t.Errorf("%v: got the assignment %q on %q (%s), want the task and its device", c.failures, result.TaskID, result.DeviceID, result.DeviceType)
+        }
+    }
+}
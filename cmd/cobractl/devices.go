@@ -0,0 +1,296 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "math/rand"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/RezanOscar/COBRA/chaincode"
+    "github.com/RezanOscar/COBRA/ledger"
+    "github.com/RezanOscar/COBRA/metrics"
+    "github.com/RezanOscar/COBRA/simulator"
+)
+
+// registerWorkers is the number of concurrent registrations
+const registerWorkers = 10
+
+// devicesRegister registers the fleet of the scenario, the devices are the ones of fleetSpecs
+func (c *cli) devicesRegister(args []string) error {
+    flags := c.flagSet("devices register")
+    scenarioPath := flags.String("scenario", "", "YAML or JSON file of the scenario whose fleet is registered")
+    flags.IntVar(&c.scenario.Fleet.EC, "ecs", c.scenario.Fleet.EC, "Number of EC")
+    flags.IntVar(&c.scenario.Fleet.UAV, "uavs", c.scenario.Fleet.UAV, "Number of UAVs")
+    flags.IntVar(&c.scenario.Fleet.HAPS, "haps", c.scenario.Fleet.HAPS, "Number of HAPS")
+    flags.IntVar(&c.scenario.Fleet.LEO, "leos", c.scenario.Fleet.LEO, "Number of LEO satellites")
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    err = c.scenario.Resolve(*scenarioPath, c.global, flags)
+    if err != nil {
+        return err
+    }
+
+    m, err := c.startMetrics()
+    if err != nil {
+        return err
+    }
+
+    specs, err := fleetSpecs(c.scenario.Fleet, rand.New(rand.NewSource(time.Now().UnixNano())))
+    if err != nil {
+        return err
+    }
+    startTime := time.Now()
+    err = c.withClient(func(client ledger.LedgerClient) error {
+        registerDevices(client, specs, true, m)
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    fmt.Printf("Total time to register devices: %s\n", time.Since(startTime))
+    return nil
+}
+
+// fleetSpecs are the devices of a fleet, the EC with a battery of 50 and 100 compute resources, the UAVs with a
+// battery of 50 and 10 compute resources and the HAPS and LEO with the defaults of their class. The DeviceIDs
+// are drawn without duplicates between 0001 and 0099, or the number of devices if it is larger, a fleet has at most
+// 9999 devices
+func fleetSpecs(fleet simulator.Fleet, rnd *rand.Rand) ([]chaincode.DeviceSpec, error) {
+    if fleet.Size() > simulator.MaxDevices {
+        return nil, fmt.Errorf("%d devices in the fleet, the device IDs allow %d", fleet.Size(), simulator.MaxDevices)
+    }
+    specs := make([]chaincode.DeviceSpec, 0, fleet.Size())
+    for i := 0; i < fleet.EC; i++ {
+        specs = append(specs, chaincode.DeviceSpec{DeviceType: "EC", BatteryLife: 50.0, ComputeResources: 100.0})
+    }
+    for i := 0; i < fleet.UAV; i++ {
+        specs = append(specs, chaincode.DeviceSpec{DeviceType: "UAV", BatteryLife: 50.0, ComputeResources: 10.0})
+    }
+    for i := 0; i < fleet.HAPS; i++ {
+        specs = append(specs, chaincode.DeviceSpec{DeviceType: "HAPS"})
+    }
+    for i := 0; i < fleet.LEO; i++ {
+        specs = append(specs, chaincode.DeviceSpec{DeviceType: "LEO"})
+    }
+
+    maxID := 99
+    if len(specs) > maxID {
+        maxID = len(specs)
+    }
+    ids := rnd.Perm(maxID)
+    for i := range specs {
+        specs[i].DeviceID = fmt.Sprintf("%04d", ids[i]+1)
+    }
+    return specs, nil
+}
+
+// registerDevices registers the devices with RegisterDeviceJSON, without overwriting the existing ones, and returns
+// the number of devices registered. The failures are always displayed, the registrations when verbose
+func registerDevices(client ledger.LedgerClient, specs []chaincode.DeviceSpec, verbose bool, m *metrics.Metrics) int {
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    registered := 0
+    sem := make(chan struct{}, registerWorkers)
+    for _, spec := range specs {
+        wg.Add(1)
+        sem <- struct{}{} // Slot
+        go func(spec chaincode.DeviceSpec) {
+            defer wg.Done()
+            defer func() { <-sem }() // Release slot
+
+            payload, err := json.Marshal(spec)
+            if err == nil {
+                _, err = client.Submit("RegisterDeviceJSON", string(payload), "false") // Do not overwrite an existing device
+            }
+            if err != nil {
+                fmt.Printf("Failed to register %s: %s\n", spec.DeviceID, err)
+                return
+            }
+            mu.Lock()
+            registered++
+            mu.Unlock()
+            m.DeviceRegistered(spec.DeviceType)
+            if verbose {
+                fmt.Printf("Registered device %s type %s with battery life %.2f and compute resources %.2f\n", spec.DeviceID, spec.DeviceType, spec.BatteryLife, spec.ComputeResources)
+            }
+        }(spec)
+    }
+    wg.Wait()
+    return registered
+}
+
+// devicesList shows the devices with an optional filter on the DeviceID, the DeviceType or the Status, or the
+// devices with a battery above 11 with battery
+func (c *cli) devicesList(args []string) error {
+    filter, err := c.parseFilter("devices list", args)
+    if err != nil {
+        return err
+    }
+
+    return c.withClient(func(client ledger.LedgerClient) error {
+        devices, err := queryDevices(client)
+        if err != nil {
+            return err
+        }
+        for _, device := range devices {
+            if filter == "" || device.DeviceID == filter || device.DeviceType == filter || device.Status == filter ||
+               (filter == "battery" && device.BatteryLife > 11.0) {
+                fmt.Printf("DeviceID: %s, Type: %s, Status: %s, Battery: %.2f, Init Battery: %.2f, ComputeResources: %.2f, TaskCompleted: %d, TotalTask: %d, TimeTask: %d, ComputeCost: %.2f, RunningTasks: %d, QueuedTasks: %d, Reputation: %.2f, PreviousReputation: %.2f, EnergyConsumed: %.2f J\n",
+                    device.DeviceID, device.DeviceType, device.Status, device.BatteryLife, device.InitialBattery, device.ComputeResources, device.TasksCompleted, device.TotalTasks, device.TimeTasks, device.ComputeCostDevice, len(device.Reservations), len(device.Queue), device.Reputation, device.PreviousReputation, device.EnergyConsumed)
+            }
+        }
+        return nil
+    })
+}
+
+// devicesUpdate changes the status or the capacity of a device, or deregisters it. The capacity flags that are
+// not set keep the current values of the device
+func (c *cli) devicesUpdate(args []string) error {
+    flags := c.flagSet("devices update")
+    status := flags.String("status", "", "New status: Available, Busy or Unavailable")
+    battery := flags.Float64("battery", 0, "New battery life")
+    initialBattery := flags.Float64("initial-battery", 0, "New initial battery")
+    initialResources := flags.Float64("initial-resources", 0, "New initial compute resources")
+    deregister := flags.Bool("deregister", false, "Decommission the device, it stays in the ledger")
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return fmt.Errorf("Usage: cobractl devices update [flags] <DeviceID>")
+    }
+    deviceID := flags.Arg(0)
+
+    set := make(map[string]bool)
+    flags.Visit(func(f *flag.Flag) {
+        set[f.Name] = true
+    })
+    capacity := set["battery"] || set["initial-battery"] || set["initial-resources"]
+    if !set["status"] && !capacity && !*deregister {
+        return fmt.Errorf("Nothing to update, use -status, -battery, -initial-battery, -initial-resources or -deregister")
+    }
+    if *deregister && (set["status"] || capacity) {
+        return fmt.Errorf("A deregistered device cannot be updated")
+    }
+
+    return c.withClient(func(client ledger.LedgerClient) error {
+        if *deregister {
+            _, err := client.Submit("DeregisterDevice", deviceID)
+            if err != nil {
+                return fmt.Errorf("Failed to deregister %s: %w", deviceID, err)
+            }
+            fmt.Printf("Deregistered device %s\n", deviceID)
+            return nil
+        }
+
+        if set["status"] {
+            _, err := client.Submit("UpdateDeviceStatus", deviceID, *status)
+            if err != nil {
+                return fmt.Errorf("Failed to update the status of %s: %w", deviceID, err)
+            }
+            fmt.Printf("Updated device %s status %s\n", deviceID, *status)
+        }
+
+        if capacity {
+            device, err := findDevice(client, deviceID)
+            if err != nil {
+                return err
+            }
+            if !set["battery"] {
+                *battery = device.BatteryLife
+            }
+            if !set["initial-battery"] {
+                *initialBattery = device.InitialBattery
+            }
+            if !set["initial-resources"] {
+                *initialResources = device.InitialResources
+            }
+
+            _, err = client.Submit("UpdateDeviceCapacity", deviceID, formatFloat(*battery), formatFloat(*initialBattery), formatFloat(*initialResources))
+            if err != nil {
+                return fmt.Errorf("Failed to update the capacity of %s: %w", deviceID, err)
+            }
+            fmt.Printf("Updated device %s battery life %.2f/%.2f and initial resources %.2f\n", deviceID, *battery, *initialBattery, *initialResources)
+        }
+        return nil
+    })
+}
+
+// devicesQueues shows the task queues of the devices with an optional filter on the DeviceID or the DeviceType,
+// or the devices with waiting tasks with waiting
+func (c *cli) devicesQueues(args []string) error {
+    filter, err := c.parseFilter("devices queues", args)
+    if err != nil {
+        return err
+    }
+
+    return c.withClient(func(client ledger.LedgerClient) error {
+        payload, err := client.Evaluate("QueryQueues")
+        if err != nil {
+            return fmt.Errorf("Failed to query queues: %w", err)
+        }
+        var queues []chaincode.QueueStatus
+        err = json.Unmarshal(payload, &queues)
+        if err != nil {
+            return fmt.Errorf("Failed to decode queues: %w", err)
+        }
+        for _, queue := range queues {
+            if filter == "" || queue.DeviceID == filter || queue.DeviceType == filter || (filter == "waiting" && len(queue.Queued) > 0) {
+                fmt.Printf("DeviceID: %s, Type: %s, Running: %d, Queued: %d/%d, ExpectedWait: %d ms, Queue: %v\n",
+                    queue.DeviceID, queue.DeviceType, queue.Running, len(queue.Queued), queue.Capacity, queue.ExpectedWait, queue.Queued)
+            }
+        }
+        return nil
+    })
+}
+
+// queryDevices reads all the devices of the ledger
+func queryDevices(client ledger.LedgerClient) ([]chaincode.Device, error) {
+    payload, err := client.Evaluate("QueryAllDevices")
+    if err != nil {
+        return nil, fmt.Errorf("Failed to query devices: %w", err)
+    }
+    var devices []chaincode.Device
+    err = json.Unmarshal(payload, &devices)
+    if err != nil {
+        return nil, fmt.Errorf("Failed to decode devices: %w", err)
+    }
+    return devices, nil
+}
+
+// findDevice reads a device of the ledger
+func findDevice(client ledger.LedgerClient, deviceID string) (chaincode.Device, error) {
+    devices, err := queryDevices(client)
+    if err != nil {
+        return chaincode.Device{}, err
+    }
+    for _, device := range devices {
+        if device.DeviceID == deviceID {
+            return device, nil
+        }
+    }
+    return chaincode.Device{}, fmt.Errorf("Device %s not found", deviceID)
+}
+
+// parseFilter parses the arguments of a list command, without flags and with an optional filter
+func (c *cli) parseFilter(name string, args []string) (string, error) {
+    flags := c.flagSet(name)
+    err := flags.Parse(args)
+    if err != nil {
+        return "", err
+    }
+    if flags.NArg() > 1 {
+        return "", fmt.Errorf("Usage: cobractl %s [filter]", name)
+    }
+    return flags.Arg(0), nil
+}
+
+// formatFloat formats a number argument of a transaction without losing precision
+func formatFloat(value float64) string {
+    return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+
+    "github.com/RezanOscar/COBRA/ledger"
+)
+
+// ledgerClean deletes the tasks, the devices or all the data of the ledger
+func (c *cli) ledgerClean(args []string) error {
+    flags := c.flagSet("ledger clean")
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return fmt.Errorf("Usage: cobractl ledger clean <tasks|devices|all>")
+    }
+    deleteType := flags.Arg(0)
+    if deleteType != "tasks" && deleteType != "devices" && deleteType != "all" {
+        return fmt.Errorf("Invalid argument: %s. Must be 'tasks', 'devices', or 'all'", deleteType)
+    }
+
+    return c.withClient(func(client ledger.LedgerClient) error {
+        _, err := client.Submit("DeleteAll", deleteType)
+        if err != nil {
+            return fmt.Errorf("Failed to delete %s: %w", deleteType, err)
+        }
+        fmt.Printf("Successfully deleted all %s.\n", deleteType)
+        return nil
+    })
+}
+
+// stats shows the aggregates of the network computed by the smart contract
+func (c *cli) stats(args []string) error {
+    flags := c.flagSet("stats")
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    if flags.NArg() > 0 {
+        return fmt.Errorf("Usage: cobractl stats")
+    }
+
+    return c.withClient(func(client ledger.LedgerClient) error {
+        stats, err := queryNetworkStats(client)
+        if err != nil {
+            return fmt.Errorf("Failed to query stats: %w", err)
+        }
+        fmt.Printf("Devices: %d, Average UAV Battery: %.2f, Available UAVs: %d, RunningTasks: %d, QueuedTasks: %d\n",
+            stats.Devices, stats.AvgUAVBattery, stats.AvailableUAVs, stats.RunningTasks, stats.QueuedTasks)
+
+        deviceTypes := make([]string, 0, len(stats.TypeStats))
+        for deviceType := range stats.TypeStats {
+            deviceTypes = append(deviceTypes, deviceType)
+        }
+        sort.Strings(deviceTypes)
+        for _, deviceType := range deviceTypes {
+            typeStats := stats.TypeStats[deviceType]
+            fmt.Printf("Type: %s, Devices: %d, Available: %d, Battery: %.2f, ComputeCost: %.2f, Tasks: %.2f, TaskShare: %.2f%%, Energy/Task: %.2f J\n",
+                deviceType, typeStats.Devices, typeStats.Available, typeStats.AvgBattery, typeStats.AvgComputeCost, typeStats.AvgTasks, typeStats.TaskShare, typeStats.EnergyPerTask)
+        }
+        fmt.Printf("Reputation: Min: %.2f, Max: %.2f, Mean: %.2f, StdDev: %.2f, Histogram (0.5 buckets): %v\n",
+            stats.Reputation.Min, stats.Reputation.Max, stats.Reputation.Mean, stats.Reputation.StdDev, stats.Reputation.Histogram)
+        return nil
+    })
+}
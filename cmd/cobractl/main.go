@@ -0,0 +1,191 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : cobractl, command-line tool of the COBRA framework
+//
+// version : 1.1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - One binary for the tools of the ledger and the simulation, the commands are
+//      devices register/list/update/queues, tasks list/submit, ledger clean, stats, simulate,
+//      sweep and report
+//      - The global flags, given before the command, select the ledger: the connection
+//      profile, the channel, the chaincode, the user and the organization of the Fabric
+//      network, the Fabric Gateway of a peer with -backend gateway, or the in-memory backend with
+//      -backend memory
+//      ex : ./cobractl devices list UAV   ./cobractl -backend memory -state ledger.json simulate -tasks 100
+//      - The global flag -metrics serves the Prometheus metrics of the commands sending
+//      transactions (devices register, tasks submit, simulate, sweep)
+//      - devices.go : registration, queries and updates of the devices
+//      - tasks.go : queries and submission of the tasks
+//      - ledger.go : cleaning and stats of the ledger
+//      - simulate.go : simulation of the task send on the ledger or, with -mode des, with a
+//      discrete-event simulation with a virtual clock and without Fabric
+//      - tasklog.go : log of the outcome of each task of a simulation in JSONL or CSV
+//      - sweep.go : simulations of a grid of parameters with several seeds, the fleet is
+//      registered again before each run and the results are aggregated in a table
+//      - report.go : charts and HTML summary of the results of a directory
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "log"
+    "os"
+    "strings"
+
+    "github.com/RezanOscar/COBRA/ledger"
+    "github.com/RezanOscar/COBRA/metrics"
+    "github.com/RezanOscar/COBRA/scenario"
+)
+
+// command is a command of cobractl, its name is one word or a group and a word (devices list)
+type command struct {
+    name string
+    args string // Arguments shown in the usage
+    help string
+    run  func(c *cli, args []string) error
+}
+
+var commands = []command{
+    {"devices register", "[-scenario file] [-ecs 3] [-uavs 27] [-haps 0] [-leos 0]", "Register a fleet of devices", (*cli).devicesRegister},
+    {"devices list", "[DeviceID|DeviceType|Status|battery]", "Show the devices", (*cli).devicesList},
+    {"devices update", "[-status S] [-battery B] [-initial-battery B] [-initial-resources R] [-deregister] <DeviceID>", "Change or deregister a device", (*cli).devicesUpdate},
+    {"devices queues", "[DeviceID|DeviceType|waiting]", "Show the task queues of the devices", (*cli).devicesQueues},
+    {"tasks list", "[DeviceID|TaskType|Status]", "Show the tasks", (*cli).tasksList},
+    {"tasks submit", "[-strategy S] [-type T] [-energy E] [-compute C] [-count N] [-data D]", "Offload tasks with a model of the smart contract", (*cli).tasksSubmit},
+    {"ledger clean", "<tasks|devices|all>", "Delete the tasks, the devices or all the data of the ledger", (*cli).ledgerClean},
+    {"stats", "", "Show the aggregates of the network computed by the smart contract", (*cli).stats},
+    {"simulate", "[-scenario file] [-mode fabric|des] [flags of the scenario]", "Simulate the task send and write the results", (*cli).simulate},
+    {"sweep", "[-scenario file] [-vary name=value1,value2 ...] [-replications N] [flags of the scenario]", "Simulate a grid of parameters with several seeds and write a table of the results", (*cli).sweep},
+    {"report", "[-in dir] [-out dir] [-format svg|png|all] [-confidence 0.95]", "Draw the charts of the results of the simulations and write an HTML summary", (*cli).report},
+}
+
+// cli is the state shared by the commands, the global flags are bound to the ledger configuration and the metrics
+// endpoint of the scenario so simulate applies them like its own flags
+type cli struct {
+    scenario    scenario.Scenario
+    global      *flag.FlagSet
+    metrics     *metrics.Metrics // nil without metrics endpoint
+    stopMetrics func() error
+}
+
+func newCLI() *cli {
+    c := &cli{
+        scenario: scenario.Default(),
+        global:   flag.NewFlagSet("cobractl", flag.ContinueOnError),
+    }
+    c.scenario.Ledger.RegisterFlags(c.global)
+    c.scenario.RegisterMetricsFlag(c.global)
+    c.global.Usage = c.usage
+    return c
+}
+
+// run parses the global flags and runs the command of the arguments
+func (c *cli) run(args []string) error {
+    err := c.global.Parse(args)
+    if err != nil {
+        return err
+    }
+
+    args = c.global.Args()
+    for _, cmd := range commands {
+        words := strings.Fields(cmd.name)
+        if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+            err := cmd.run(c, args[len(words):])
+            if c.stopMetrics != nil {
+                c.stopMetrics()
+                c.stopMetrics = nil
+            }
+            return err
+        }
+    }
+
+    c.usage()
+    if len(args) == 0 {
+        return fmt.Errorf("Missing command")
+    }
+    for _, cmd := range commands {
+        if len(args) > 1 && strings.HasPrefix(cmd.name, args[0]+" ") {
+            return fmt.Errorf("Unknown command %s %s", args[0], args[1])
+        }
+    }
+    return fmt.Errorf("Unknown command %s", args[0])
+}
+
+// usage lists the commands and the global flags
+func (c *cli) usage() {
+    output := c.global.Output()
+    fmt.Fprintf(output, "Usage: cobractl [global flags] <command> [flags] [arguments]\n\nCommands:\n")
+    for _, cmd := range commands {
+        fmt.Fprintf(output, "  %s\n        %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.help)
+    }
+    fmt.Fprintf(output, "\nGlobal flags:\n")
+    c.global.PrintDefaults()
+}
+
+// flagSet creates the flag set of a command
+func (c *cli) flagSet(name string) *flag.FlagSet {
+    flags := flag.NewFlagSet("cobractl "+name, flag.ContinueOnError)
+    flags.SetOutput(c.global.Output())
+    return flags
+}
+
+// startMetrics serves the metrics on the address of the scenario when the command starts sending transactions, the
+// metrics are nil without address and they are kept by the next commands of c
+func (c *cli) startMetrics() (*metrics.Metrics, error) {
+    if c.scenario.Metrics == "" {
+        return nil, nil
+    }
+    if c.stopMetrics != nil {
+        return c.metrics, nil // Already served
+    }
+    if c.metrics == nil {
+        c.metrics = metrics.New()
+    }
+    addr, stop, err := c.metrics.Serve(c.scenario.Metrics)
+    if err != nil {
+        return nil, err
+    }
+    fmt.Printf("Metrics served on http://%s/metrics\n", addr)
+    c.stopMetrics = stop
+    return c.metrics, nil
+}
+
+// withClient connects to the ledger of the global flags, runs fn and closes the client
+func (c *cli) withClient(fn func(client ledger.LedgerClient) error) error {
+    return withLedger(c.scenario.Ledger, fn)
+}
+
+// withLedger connects to the ledger of the configuration, runs fn and closes the client, the memory backend saves
+// its world state when it is closed
+func withLedger(config ledger.Config, fn func(client ledger.LedgerClient) error) error {
+    client, err := ledger.New(config)
+    if err != nil {
+        return fmt.Errorf("Failed to initialize the ledger client: %w", err)
+    }
+
+    err = fn(client)
+    closeErr := client.Close()
+    if err != nil {
+        return err
+    }
+    if closeErr != nil {
+        return fmt.Errorf("Failed to close the ledger client: %w", closeErr)
+    }
+    return nil
+}
+
+func main() {
+    err := newCLI().run(os.Args[1:])
+    if errors.Is(err, flag.ErrHelp) {
+        return
+    }
+    if err != nil {
+        log.Fatalf("%v", err)
+    }
+}
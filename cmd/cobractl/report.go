@@ -0,0 +1,36 @@
+package main
+
+import (
+    "fmt"
+
+    "github.com/RezanOscar/COBRA/report"
+)
+
+// report draws the charts of the results of the simulations of a directory and writes the HTML summary, it does not
+// use the ledger
+func (c *cli) report(args []string) error {
+    flags := c.flagSet("report")
+    in := flags.String("in", c.scenario.Output, "Directory of the results graphe_result_*.csv and of the task logs tasks_*")
+    out := flags.String("out", "", "Directory of the charts and of report.html, the directory of the results if empty")
+    format := flags.String("format", report.FormatSVG, "Format of the charts: svg, png or all")
+    level := flags.Float64("confidence", c.scenario.Stats.Confidence, "Confidence level of the intervals of the latencies")
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    if flags.NArg() > 0 {
+        return fmt.Errorf("Usage: cobractl report [flags]")
+    }
+    if *out == "" {
+        *out = *in
+    }
+
+    files, err := report.Generate(*in, *out, *format, *level)
+    for _, file := range files {
+        fmt.Printf("Written %s\n", file)
+    }
+    if err != nil {
+        return fmt.Errorf("Failed to write the report: %w", err)
+    }
+    return nil
+}
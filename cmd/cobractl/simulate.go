@@ -0,0 +1,746 @@
+package main
+
+import (
+    cryptoRand "crypto/rand"
+    "encoding/csv"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "math/rand"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/RezanOscar/COBRA/chaincode"
+    "github.com/RezanOscar/COBRA/ledger"
+    "github.com/RezanOscar/COBRA/loadgen"
+    "github.com/RezanOscar/COBRA/metrics"
+    "github.com/RezanOscar/COBRA/scenario"
+    "github.com/RezanOscar/COBRA/scheduler"
+    "github.com/RezanOscar/COBRA/simulator"
+    "github.com/RezanOscar/COBRA/stats"
+)
+
+// Generates a random string for task data
+func generateRandomString(length int) (string, error) {
+    bytes := make([]byte, length)
+    _, err := cryptoRand.Read(bytes)
+    if err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(bytes)[:length], nil
+}
+
+// Queries the aggregates of all devices computed by the smart contract
+func queryNetworkStats(client ledger.LedgerClient) (scheduler.NetworkStats, error) {
+    var stats scheduler.NetworkStats
+    payload, err := client.Evaluate("GetNetworkStats")
+    if err != nil {
+        return stats, err
+    }
+
+    err = json.Unmarshal(payload, &stats)
+    return stats, err
+}
+
+// errorCode extracts the code of the smart contract error from the error returned by the ledger client, the errors
+// without code are classified by the ledger (MVCC_CONFLICT, ENDORSEMENT_MISMATCH, TIMEOUT or OTHER)
+func errorCode(err error) string {
+    message := err.Error()
+    index := strings.Index(message, `{"code":`)
+    if index < 0 {
+        return ledger.Classify(err)
+    }
+
+    var contractError chaincode.ContractError
+    if json.NewDecoder(strings.NewReader(message[index:])).Decode(&contractError) != nil || contractError.Code == "" {
+        return ledger.Classify(err)
+    }
+    return contractError.Code
+}
+
+// Actions of the retry policy after a failed attempt
+const (
+    retryStop     = iota // The task is rejected for good
+    retryResubmit        // The transaction is not committed, the task is sent again after the backoff
+    retryCheck           // The transaction may be committed, its status is checked before sending the task again
+)
+
+// retryAction is the retry policy of a task failed with this code: the business rejections that cannot change are
+// not retried, the batteries are not recharged and the task and the client do not change, but the devices can be
+// released (NO_CANDIDATE). The MVCC conflicts and the endorsement mismatches are never committed, a timeout or an
+// unknown error can happen after the commit: the status is checked first, the task sent again with its request ID
+// gets its first assignment anyway
+func retryAction(code string) int {
+    switch code {
+    case chaincode.ErrInsufficientBattery, chaincode.ErrUnknownTaskType, chaincode.ErrUnauthorized:
+        return retryStop
+    case ledger.ErrTimeout, ledger.ErrOther:
+        return retryCheck
+    }
+    return retryResubmit
+}
+
+// committed checks if the transaction of a failed Submit is committed, when its TxID is known and its status found
+func committed(client ledger.LedgerClient, err error) bool {
+    txID := ledger.TxID(err)
+    if txID == "" {
+        return false
+    }
+    status, err := client.Status(txID)
+    return err == nil && status == ledger.StatusCommitted
+}
+
+// setAssignment fills the result with the assignment returned by the offload function
+func setAssignment(result *loadgen.Result, payload []byte) {
+    var assignment chaincode.Assignment
+    if json.Unmarshal(payload, &assignment) == nil {
+        result.TaskID = assignment.TaskID
+        result.DeviceID = assignment.DeviceID
+        result.DeviceType = assignment.DeviceType
+        result.BatteryLife = assignment.BatteryLife
+    }
+}
+
+// offloadArgs are the arguments of the offload model of the scenario for a task, the request ID is the same for all
+// the attempts of the task so that the smart contract does not assign it twice
+func offloadArgs(scn scenario.Scenario, taskData string, taskType simulator.TaskType, requestID string) []string {
+    args := []string{
+        taskData,
+        taskType.Name,
+        fmt.Sprintf("%.2f", taskType.EnergyCost),
+        fmt.Sprintf("%.2f", taskType.ComputeCost),
+    }
+    if scn.Strategy == "TaskOffloadCobra" {
+        args = append(args,
+            fmt.Sprintf("%.2f", scn.Lambda),  // Pass lambda to the blockchain
+            fmt.Sprintf("%.2f", scn.Epsilon), // Pass epsilon to the blockchain
+        )
+    }
+    return append(args, requestID)
+}
+
+// newSender sends the tasks of the load to the blockchain with the retry policy and a jittered exponential backoff
+// between the attempts, the clients send their tasks at the same time. Each attempt is counted in the metrics.
+// The attempts of a task share a request ID: a task sent again after a commit that was not seen gets its first
+// assignment back, as a task whose commit was found after a timeout
+func newSender(client ledger.LedgerClient, scn scenario.Scenario, m *metrics.Metrics) loadgen.Sender {
+    backoff := simulator.Backoff{Base: time.Duration(scn.Backoff.Base), Max: time.Duration(scn.Backoff.Max)}
+    return func(task loadgen.Task) loadgen.Result {
+        var result loadgen.Result
+        requestID, err := generateRandomString(32)
+        if err != nil {
+            result.ErrorCode = ledger.ErrOther
+            return result
+        }
+        args := offloadArgs(scn, task.TaskData, task.TaskType, requestID)
+        m.TaskIssued(scn.Strategy, task.TaskType.Name)
+
+        for attempt := 1; attempt <= scn.MaxRetries; attempt++ {
+            result.Attempts = attempt
+            result.Start = time.Now()
+            payload, err := client.Submit(scn.Strategy, args...)
+            result.End = time.Now()
+            m.Attempt(scn.Strategy, task.TaskType.Name, result.End.Sub(result.Start))
+
+            if err == nil {
+                result.Success = true
+                result.ErrorCode = ""
+                setAssignment(&result, payload)
+                break
+            }
+
+            result.ErrorCode = errorCode(err)
+            action := retryAction(result.ErrorCode)
+            if action == retryCheck && committed(client, err) {
+                // Committed despite the error, the task is assigned and its TaskID is the TxID. The offload evaluated
+                // with the request ID returns the assignment of the committed transaction without assigning it again
+                result.Success = true
+                result.ErrorCode = ""
+                result.TaskID = ledger.TxID(err)
+                if payload, err := client.Evaluate(scn.Strategy, args...); err == nil {
+                    setAssignment(&result, payload)
+                }
+                break
+            }
+            if action == retryStop || attempt == scn.MaxRetries {
+                break
+            }
+
+            time.Sleep(backoff.Delay(attempt, rand.Float64()))
+        }
+        return result
+    }
+}
+
+// Write results into the CSV with additional stats, including TotalTaskUAV and TotalTaskEC in percentage
+func writeResultsToCSV(filename string, reportInterval int, uavBatteryAvg []float64, uavAvailable []int, avgComputeCostAll []float64, avgComputeCostUAV []float64, avgComputeCostEC []float64, avgTasksUAV []float64, avgTasksEC []float64, timeDelay []float64, totalTaskUAVPercentage []float64, totalTaskECPercentage []float64, energyTaskUAV []float64, energyTaskEC []float64) error {
+    // Convert the uavAvailable slice from []int to []float64
+    uavAvailableFloat := intSliceToFloat64Slice(uavAvailable)
+
+    // Find the minimum length to avoid index out-of-range errors
+    minLength := minSliceLength(uavBatteryAvg, uavAvailableFloat, avgComputeCostAll, avgComputeCostUAV, avgComputeCostEC, avgTasksUAV, avgTasksEC, timeDelay, energyTaskUAV, energyTaskEC)
+
+    file, err := os.Create(filename)
+    if err != nil {
+        return fmt.Errorf("failed to create CSV file: %w", err)
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    header := []string{
+        "Total Tasks", "UAV Battery Avg", "UAV Available", "Avg ComputeCost (All)", "Avg ComputeCost (UAV)", "Avg ComputeCost (EC)",
+        "Avg Tasks (UAV)", "Avg Tasks (EC)", "Time Delay", "TotalTaskUAV (%)", "TotalTaskEC (%)",
+        "Energy/Task (UAV) (J)", "Energy/Task (EC) (J)",
+    }
+    err = writer.Write(header)
+    if err != nil {
+        return fmt.Errorf("failed to write CSV header: %w", err)
+    }
+
+    for i := 0; i < minLength; i++ {
+        row := []string{
+            fmt.Sprintf("%d", i*reportInterval), // Total tasks up to this point
+            fmt.Sprintf("%.2f", uavBatteryAvg[i]*2), // Battery Avg multiplied by 2
+            fmt.Sprintf("%.0f", uavAvailableFloat[i]), // No decimals for UAV Available
+            fmt.Sprintf("%.2f", avgComputeCostAll[i]),
+            fmt.Sprintf("%.2f", avgComputeCostUAV[i]),
+            fmt.Sprintf("%.2f", avgComputeCostEC[i]),
+            fmt.Sprintf("%.2f", avgTasksUAV[i]),
+            fmt.Sprintf("%.2f", avgTasksEC[i]),
+            fmt.Sprintf("%.2f", timeDelay[i]),
+            fmt.Sprintf("%.2f", totalTaskUAVPercentage[i]), // TotalTaskUAV% 
+            fmt.Sprintf("%.2f", totalTaskECPercentage[i]),  // TotalTaskEC%
+            fmt.Sprintf("%.2f", energyTaskUAV[i]),
+            fmt.Sprintf("%.2f", energyTaskEC[i]),
+        }
+        err := writer.Write(row)
+        if err != nil {
+            return fmt.Errorf("failed to write CSV row: %w", err)
+        }
+    }
+
+    return nil
+}
+
+
+// Helper function to convert []int to []float64
+func intSliceToFloat64Slice(intSlice []int) []float64 {
+    float64Slice := make([]float64, len(intSlice))
+    for i, val := range intSlice {
+        float64Slice[i] = float64(val)
+    }
+    return float64Slice
+}
+
+// Find the minimum slice length among the slices
+func minSliceLength(slices ...interface{}) int {
+    minLength := len(slices[0].([]float64))
+    for _, slice := range slices[1:] {
+        length := len(slice.([]float64))
+        if length < minLength {
+            minLength = length
+        }
+    }
+    return minLength
+}
+
+// runDES offloads the tasks with the discrete-event simulation on an in-memory fleet and a virtual clock, the
+// CSV has the same columns as the one of the blockchain and the times are virtual. The metrics are updated once
+// the simulation is done, with the virtual latencies
+func runDES(scn scenario.Scenario, m *metrics.Metrics) (summary, error) {
+    wallStart := time.Now()
+
+    // The scenario is recorded with the results
+    scenarioFile, err := scn.Save("scenario_des_" + scn.Strategy)
+    if err != nil {
+        return summary{}, err
+    }
+    fmt.Printf("Scenario saved in %s\n", scenarioFile)
+
+    // Same tasks and arrivals as on the blockchain, drawn with the seed
+    tasks, arrivals, err := schedule(scn)
+    if err != nil {
+        return summary{}, err
+    }
+    config := simulator.Config{
+        Model:          scn.Strategy,
+        Tasks:          tasks,
+        Fleet:          scn.Fleet,
+        Clients:        scn.Clients,
+        Arrivals:       arrivals,
+        Lambda:         scn.Lambda,
+        Epsilon:        scn.Epsilon,
+        MaxRetries:     scn.MaxRetries,
+        Backoff:        simulator.Backoff{Base: time.Duration(scn.Backoff.Base), Max: time.Duration(scn.Backoff.Max)},
+        TxLatency:      time.Duration(scn.DES.Latency),
+        TxJitter:       time.Duration(scn.DES.Jitter),
+        ReportInterval: scn.ReportInterval,
+        Seed:           scn.Seed,
+        Start:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+    }
+    result, err := simulator.Run(config)
+    if err != nil {
+        return summary{}, err
+    }
+
+    var uavBatteryAvg, avgComputeCostAll, avgComputeCostUAV, avgComputeCostEC, avgTasksUAV, avgTasksEC, timeDelay []float64
+    var totalTaskUAVPercentage, totalTaskECPercentage, energyTaskUAV, energyTaskEC []float64
+    var uavAvailable []int
+    for _, report := range result.Reports {
+        stats := report.Stats
+        uavBatteryAvg = append(uavBatteryAvg, stats.AvgUAVBattery)
+        uavAvailable = append(uavAvailable, stats.AvailableUAVs)
+        avgComputeCostAll = append(avgComputeCostAll, stats.AvgComputeCost)
+        avgComputeCostUAV = append(avgComputeCostUAV, stats.AvgComputeCostUAV)
+        avgComputeCostEC = append(avgComputeCostEC, stats.AvgComputeCostEC)
+        avgTasksUAV = append(avgTasksUAV, stats.AvgTasksUAV)
+        avgTasksEC = append(avgTasksEC, stats.AvgTasksEC)
+        timeDelay = append(timeDelay, report.Elapsed.Seconds())
+        totalTaskUAVPercentage = append(totalTaskUAVPercentage, stats.TaskShareUAV)
+        totalTaskECPercentage = append(totalTaskECPercentage, stats.TaskShareEC)
+        energyTaskUAV = append(energyTaskUAV, stats.EnergyPerTaskUAV)
+        energyTaskEC = append(energyTaskEC, stats.EnergyPerTaskEC)
+
+        if report.Tasks > 0 && report.Tasks%scn.ReportIntervalScreen == 0 {
+            fmt.Printf("After %d tasks:\n - Average UAV Battery: %.2f%%\n - Available UAVs: %d\n - Virtual time elapsed: %.2f seconds\n", report.Tasks, stats.AvgUAVBattery*2, stats.AvailableUAVs, report.Elapsed.Seconds())
+        }
+    }
+
+    csvFilename := filepath.Join(scn.Output, fmt.Sprintf("graphe_result_des_%s.csv", scn.Strategy))
+    err = writeResultsToCSV(csvFilename, scn.ReportInterval, uavBatteryAvg, uavAvailable, avgComputeCostAll, avgComputeCostUAV, avgComputeCostEC, avgTasksUAV, avgTasksEC, timeDelay, totalTaskUAVPercentage, totalTaskECPercentage, energyTaskUAV, energyTaskEC)
+    if err != nil {
+        return summary{}, err
+    }
+
+    tasksLog, err := newTaskLog(scn, "tasks_des_"+scn.Strategy)
+    if err != nil {
+        return summary{}, err
+    }
+    defer tasksLog.close()
+
+    successCount := 0
+    failCount := 0
+    failuresByCode := make(map[string]int)
+    durations := make([]float64, 0, len(result.Tasks))
+    waits := make([]float64, 0, len(result.Tasks))
+    var timesAt []time.Duration
+    for i, task := range result.Tasks {
+        if task.Success {
+            successCount++
+        } else {
+            failCount++
+            failuresByCode[task.ErrorCode]++
+        }
+        durations = append(durations, task.End.Sub(task.Start).Seconds())
+        waits = append(waits, task.Issued.Sub(task.Arrival).Seconds())
+        m.TaskIssued(scn.Strategy, task.TaskType)
+        m.Attempt(scn.Strategy, task.TaskType, task.End.Sub(task.Start))
+        m.TaskDone(scn.Strategy, task.TaskType, task.Success, task.DeviceType, task.ErrorCode, task.Attempts, task.Issued.Sub(task.Arrival))
+        if (i+1)%10 == 0 && i < 70 {
+            timesAt = append(timesAt, task.End.Sub(config.Start))
+        }
+
+        err = tasksLog.write(taskRecord{
+            TaskID:        task.TaskID,
+            TaskType:      task.TaskType,
+            Strategy:      scn.Strategy,
+            DeviceID:      task.DeviceID,
+            DeviceType:    task.DeviceType,
+            Arrival:       task.Arrival,
+            Submitted:     task.Issued,
+            LastSubmitted: task.Start,
+            Committed:     task.End,
+            Attempts:      task.Attempts,
+            Success:       task.Success,
+            ErrorCode:     task.ErrorCode,
+            BatteryLife:   task.BatteryLife,
+        })
+        if err != nil {
+            return summary{}, fmt.Errorf("Failed to write the task log: %w", err)
+        }
+    }
+    err = tasksLog.close()
+    if err != nil {
+        return summary{}, err
+    }
+
+    final := scheduler.Stats(result.Devices)
+    m.NetworkStats(final)
+    network := fmt.Sprintf("in-memory (%d devices, %s)", final.Devices, loadDescription(scn))
+    printSummary(result.Elapsed, len(result.Tasks), successCount, failCount, failuresByCode, durations, waits, scn.Stats, final.EnergyPerTaskUAV, final.EnergyPerTaskEC, scn.Strategy, network, timesAt)
+    fmt.Printf("Simulated in %.2f seconds of wall-clock time\n", time.Since(wallStart).Seconds())
+    return newSummary(result.Elapsed, successCount, failCount, durations, waits, scn.Stats, final), nil
+}
+
+// simulate sends the tasks of the scenario to the ledger, or offloads them with the discrete-event simulation
+// with -mode des
+func (c *cli) simulate(args []string) error {
+    // The global flags of the ledger and the flags of simulate are bound to the scenario of c
+    flags := c.flagSet("simulate")
+    scenarioPath := flags.String("scenario", "", "YAML or JSON file of the scenario, the other flags override its values")
+    c.scenario.RegisterFlags(flags)
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    err = c.scenario.Resolve(*scenarioPath, c.global, flags)
+    if err != nil {
+        return err
+    }
+    scn := c.scenario
+
+    m, err := c.startMetrics()
+    if err != nil {
+        return err
+    }
+
+    if scn.Mode == scenario.ModeDES {
+        _, err := runDES(scn, m)
+        if err != nil {
+            return fmt.Errorf("Discrete-event simulation failed: %w", err)
+        }
+        return nil
+    }
+
+    // The scenario is recorded with the results
+    scenarioFile, err := scn.Save("scenario_" + scn.Strategy)
+    if err != nil {
+        return err
+    }
+    fmt.Printf("Scenario saved in %s\n", scenarioFile)
+
+    return c.withClient(func(client ledger.LedgerClient) error {
+        _, err := runFabric(client, scn, m)
+        return err
+    })
+}
+
+// runFabric sends the tasks of the scenario to the smart contract with the load generator and writes the stats of
+// the devices every report interval of finished tasks in graphe_result_<function>.csv. In the closed loop each
+// client sends its next task when the previous one is done, with a rate the tasks are sent at their arrival time
+// with at most Clients tasks in flight. The metrics follow the tasks and the stats of the devices
+func runFabric(client ledger.LedgerClient, scn scenario.Scenario, m *metrics.Metrics) (summary, error) {
+    // The task distribution and the arrivals of the tasks, fewer tasks if the arrival processes end before
+    taskDistribution, arrivals, err := schedule(scn)
+    if err != nil {
+        return summary{}, err
+    }
+    numTasks := len(taskDistribution)
+    reportInterval := scn.ReportInterval
+
+    successCount := 0
+    failCount := 0
+    failuresByCode := make(map[string]int) // Failed tasks by error code of the smart contract
+    durations := make([]float64, 0, numTasks) // Track task durations
+    waits := make([]float64, 0, numTasks)     // Track the waits for a free client
+
+    // Initial stats
+    stats, err := queryNetworkStats(client)
+    if err != nil {
+        return summary{}, fmt.Errorf("Failed to query network stats: %w", err)
+    }
+    m.NetworkStats(stats)
+
+    uavBatteryAvg := make([]float64, 0, (numTasks/reportInterval)+1)
+    uavAvailable := make([]int, 0, (numTasks/reportInterval)+1)
+    timeDelay := make([]float64, 0, (numTasks/reportInterval)+1)
+
+    avgBattery, availableUAVs := stats.AvgUAVBattery, stats.AvailableUAVs
+    fmt.Printf("Initial state:\n - Average UAV Battery: %.2f%%\n - Available UAVs: %d\n", avgBattery*2, availableUAVs)
+    uavBatteryAvg = append(uavBatteryAvg, avgBattery) 
+    uavAvailable = append(uavAvailable, availableUAVs)
+    timeDelay = append(timeDelay, 0.0)
+
+    avgComputeCostAll := make([]float64, 0, (numTasks/reportInterval)+1)
+    avgComputeCostUAV := make([]float64, 0, (numTasks/reportInterval)+1)
+    avgComputeCostEC := make([]float64, 0, (numTasks/reportInterval)+1)
+    avgTasksUAV := make([]float64, 0, (numTasks/reportInterval)+1)
+    avgTasksEC := make([]float64, 0, (numTasks/reportInterval)+1)
+    totalTaskUAVPercentage := make([]float64, 0, (numTasks/reportInterval)+1)
+    totalTaskECPercentage := make([]float64, 0, (numTasks/reportInterval)+1)
+    energyTaskUAV := make([]float64, 0, (numTasks/reportInterval)+1)
+    energyTaskEC := make([]float64, 0, (numTasks/reportInterval)+1)
+
+
+
+    // Gather initial device stats before any tasks are processed
+    avgComputeCostAll = append(avgComputeCostAll, stats.AvgComputeCost)
+    avgComputeCostUAV = append(avgComputeCostUAV, stats.AvgComputeCostUAV)
+    avgComputeCostEC = append(avgComputeCostEC, stats.AvgComputeCostEC)
+    avgTasksUAV = append(avgTasksUAV, stats.AvgTasksUAV)
+    avgTasksEC = append(avgTasksEC, stats.AvgTasksEC)
+
+    totalTaskUAVPercentage = append(totalTaskUAVPercentage, stats.TaskShareUAV)
+    totalTaskECPercentage = append(totalTaskECPercentage, stats.TaskShareEC)
+
+    energyUAV, energyEC := stats.EnergyPerTaskUAV, stats.EnergyPerTaskEC
+    energyTaskUAV = append(energyTaskUAV, energyUAV)
+    energyTaskEC = append(energyTaskEC, energyEC)
+
+    // Generate the load with the data of the tasks
+    tasks := make([]loadgen.Task, numTasks)
+    for i := range tasks {
+        taskData, err := generateRandomString(8)
+        if err != nil {
+            return summary{}, fmt.Errorf("Failed to generate task data: %w", err)
+        }
+        tasks[i] = loadgen.Task{Index: i, TaskType: taskDistribution[i], TaskData: taskData}
+        if arrivals != nil {
+            tasks[i].At = arrivals[i]
+        }
+    }
+
+    // Times after the first 10, 20 ... 70 finished tasks
+    var timesAt []time.Duration
+
+    csvFilename := filepath.Join(scn.Output, fmt.Sprintf("graphe_result_%s.csv", scn.Strategy))
+    tasksLog, err := newTaskLog(scn, "tasks_"+scn.Strategy)
+    if err != nil {
+        return summary{}, err
+    }
+    defer tasksLog.close()
+
+    // Process all tasks, the stats are read while the next tasks are in flight
+    startTime := time.Now()
+    results := loadgen.Start(loadgen.Config{Concurrency: scn.Clients, OpenLoop: arrivals != nil}, tasks, newSender(client, scn, m))
+    finished := 0
+    for result := range results {
+        finished++
+        if result.Success {
+            successCount++
+        } else {
+            failCount++
+            failuresByCode[result.ErrorCode]++
+        }
+        durations = append(durations, result.End.Sub(result.Start).Seconds())
+        waits = append(waits, result.Wait().Seconds())
+        m.TaskDone(scn.Strategy, result.Task.TaskType.Name, result.Success, result.DeviceType, result.ErrorCode, result.Attempts, result.Wait())
+
+        err = tasksLog.write(taskRecord{
+            TaskID:        result.TaskID,
+            TaskType:      result.Task.TaskType.Name,
+            Strategy:      scn.Strategy,
+            DeviceID:      result.DeviceID,
+            DeviceType:    result.DeviceType,
+            Arrival:       result.Arrival,
+            Submitted:     result.Issued,
+            LastSubmitted: result.Start,
+            Committed:     result.End,
+            Attempts:      result.Attempts,
+            Success:       result.Success,
+            ErrorCode:     result.ErrorCode,
+            BatteryLife:   result.BatteryLife,
+        })
+        if err != nil {
+            return summary{}, fmt.Errorf("Failed to write the task log: %w", err)
+        }
+
+        elapsed := time.Since(startTime).Seconds()
+        if finished%10 == 0 && finished <= 70 {
+            timesAt = append(timesAt, time.Since(startTime))
+        }
+
+        // Report interval for stats, a row without the stats would shift the total tasks of the next rows so the
+        // simulation stops if they cannot be read
+        if finished%reportInterval == 0 {
+            stats, err = queryNetworkStats(client)
+            if err != nil {
+                return summary{}, fmt.Errorf("Failed to query network stats after %d tasks: %w", finished, err)
+            }
+            m.NetworkStats(stats)
+            avgBattery, availableUAVs = stats.AvgUAVBattery, stats.AvailableUAVs
+
+            uavBatteryAvg = append(uavBatteryAvg, avgBattery)
+            uavAvailable = append(uavAvailable, availableUAVs)
+            avgComputeCostAll = append(avgComputeCostAll, stats.AvgComputeCost)
+            avgComputeCostUAV = append(avgComputeCostUAV, stats.AvgComputeCostUAV)
+            avgComputeCostEC = append(avgComputeCostEC, stats.AvgComputeCostEC)
+            avgTasksUAV = append(avgTasksUAV, stats.AvgTasksUAV)
+            avgTasksEC = append(avgTasksEC, stats.AvgTasksEC)
+            timeDelay = append(timeDelay, elapsed)
+            totalTaskUAVPercentage = append(totalTaskUAVPercentage, stats.TaskShareUAV)
+            totalTaskECPercentage = append(totalTaskECPercentage, stats.TaskShareEC)
+            energyUAV, energyEC = stats.EnergyPerTaskUAV, stats.EnergyPerTaskEC
+            energyTaskUAV = append(energyTaskUAV, energyUAV)
+            energyTaskEC = append(energyTaskEC, energyEC)
+
+
+            // Write updated stats to CSV after every reportInterval
+            err = writeResultsToCSV(csvFilename, reportInterval, uavBatteryAvg, uavAvailable, avgComputeCostAll, avgComputeCostUAV, avgComputeCostEC, avgTasksUAV, avgTasksEC, timeDelay, totalTaskUAVPercentage, totalTaskECPercentage, energyTaskUAV, energyTaskEC)
+            if err != nil {
+                return summary{}, fmt.Errorf("Failed to write results to CSV: %w", err)
+            }
+        }
+
+
+        // Screen Stats Display Interval, skipped if the stats cannot be read
+        if finished%scn.ReportIntervalScreen == 0  {
+            stats, err = queryNetworkStats(client)
+            if err != nil {
+                fmt.Printf("After %d tasks: failed to query network stats: %v\n", finished, err)
+                continue
+            }
+            m.NetworkStats(stats)
+            avgBattery, availableUAVs = stats.AvgUAVBattery, stats.AvailableUAVs
+
+            fmt.Printf("After %d tasks:\n - Average UAV Battery: %.2f%%\n - Available UAVs: %d\n - Time elapsed: %.2f seconds\n", finished, avgBattery*2, availableUAVs, elapsed)
+        }
+    }
+
+    endTime := time.Now()
+    duration := endTime.Sub(startTime)
+    err = tasksLog.close()
+    if err != nil {
+        return summary{}, err
+    }
+
+    final, err := queryNetworkStats(client)
+    if err != nil {
+        return summary{}, fmt.Errorf("Failed to query network stats: %w", err)
+    }
+    m.NetworkStats(final)
+
+    network := fmt.Sprintf("%s (%s backend, %s)", scn.Ledger.Chaincode, scn.Ledger.Backend, loadDescription(scn))
+    printSummary(duration, numTasks, successCount, failCount, failuresByCode, durations, waits, scn.Stats, energyUAV, energyEC, scn.Strategy, network, timesAt)
+    return newSummary(duration, successCount, failCount, durations, waits, scn.Stats, final), nil
+}
+
+// schedule returns the tasks of the scenario and their arrival times, the ones of the trace if any with its
+// rejected records displayed
+func schedule(scn scenario.Scenario) ([]simulator.TaskType, []time.Duration, error) {
+    if scn.Trace.Path == "" {
+        tasks, arrivals := scn.Schedule()
+        return tasks, arrivals, nil
+    }
+
+    tasks, arrivals, rejected, err := scn.Replay()
+    if len(rejected) > 0 {
+        fmt.Printf("Rejected %d records of the trace %s:\n", len(rejected), scn.Trace.Path)
+        for _, rejection := range rejected {
+            fmt.Printf(" - line %d: %s\n", rejection.Line, rejection.Reason)
+        }
+    }
+    if err != nil {
+        return nil, nil, err
+    }
+    fmt.Printf("Replaying %d records of the trace %s with the inter-arrival times x%g\n", len(tasks), scn.Trace.Path, scn.Trace.Scale)
+    return tasks, arrivals, nil
+}
+
+// loadDescription describes the load of the scenario in the summary
+func loadDescription(scn scenario.Scenario) string {
+    if scn.Trace.Path != "" {
+        return fmt.Sprintf("replay of %s with at most %d in flight", filepath.Base(scn.Trace.Path), scn.Clients)
+    }
+    if len(scn.Arrivals) > 0 {
+        return fmt.Sprintf("open loop of %d arrival processes with at most %d in flight", len(scn.Arrivals), scn.Clients)
+    }
+    if scn.Rate > 0 {
+        return fmt.Sprintf("open loop at %.2f tasks/s with at most %d in flight", scn.Rate, scn.Clients)
+    }
+    return fmt.Sprintf("closed loop of %d clients", scn.Clients)
+}
+
+// summary are the results of a simulation compared by the sweeps
+type summary struct {
+    Tasks             int
+    SuccessRate       float64 // % of the tasks offloaded
+    Duration          float64 // Total time in s, virtual in the discrete-event simulation
+    MeanTaskDuration  float64 // s
+    P95TaskDuration   float64 // s
+    MeanWait          float64 // s waited for a free client
+    Bandwidth         float64 // Tasks per second
+    EnergyPerTaskUAV  float64 // J
+    EnergyPerTaskEC   float64 // J
+    UAVBattery        float64 // % of the battery of the UAVs left at the end
+    AvailableUAVs     int
+    TaskShareUAV      float64 // % of the tasks of the UAVs and ECs assigned to the UAVs
+}
+
+// newSummary computes the summary of a simulation from the durations of the tasks after the warm-up and the final
+// stats of the devices
+func newSummary(duration time.Duration, successCount int, failCount int, durations []float64, waits []float64, config scenario.Stats, final scheduler.NetworkStats) summary {
+    taskCount := successCount + failCount
+    durations = stats.WarmUp(durations, config.WarmUp)
+    return summary{
+        Tasks:             taskCount,
+        SuccessRate:       100 * float64(successCount) / float64(taskCount),
+        Duration:          duration.Seconds(),
+        MeanTaskDuration:  stats.Mean(durations),
+        P95TaskDuration:   stats.Percentile(durations, 95),
+        MeanWait:          stats.Mean(stats.WarmUp(waits, config.WarmUp)),
+        Bandwidth:         float64(taskCount) / duration.Seconds(),
+        EnergyPerTaskUAV:  final.EnergyPerTaskUAV,
+        EnergyPerTaskEC:   final.EnergyPerTaskEC,
+        UAVBattery:        final.AvgUAVBattery * 2, // Battery of 50 at 100%
+        AvailableUAVs:     final.AvailableUAVs,
+        TaskShareUAV:      final.TaskShareUAV,
+    }
+}
+
+// printSummary displays the results of a simulation, waits are the times the tasks waited for a free client and
+// timesAt are the times after the first 10, 20 ... 70 finished tasks. The statistics of the durations and the waits
+// exclude the warm-up of config
+func printSummary(duration time.Duration, taskCount int, successCount int, failCount int, failuresByCode map[string]int, durations []float64, waits []float64, config scenario.Stats, energyUAV float64, energyEC float64, strategy string, network string, timesAt []time.Duration) {
+    totalDuration := 0.0
+    for _, taskDuration := range durations {
+        totalDuration += taskDuration
+    }
+
+    // Percentiles and confidence intervals of the task durations and of the waits after the warm-up
+    durationStats := stats.Summarize(stats.WarmUp(durations, config.WarmUp), config.Confidence, config.Batches)
+    waitStats := stats.Summarize(stats.WarmUp(waits, config.WarmUp), config.Confidence, config.Batches)
+
+    bandwidth := float64(taskCount) / duration.Seconds()
+
+    // Calculate confirmation and consensus time (assuming they are the same for this simulation)
+    transactionConfirmationTime := totalDuration / float64(taskCount)
+    consensusTime := transactionConfirmationTime
+
+    // Display results 
+    fmt.Printf("=====================================\n")
+    fmt.Printf("Simulation Complete\n")
+    fmt.Printf("Total Time: %.2f seconds\n", duration.Seconds())
+    fmt.Printf("Number of Tasks Sent: %d\n", taskCount)
+    fmt.Printf("Successful Tasks: %d\n", successCount)
+    fmt.Printf("Failed Tasks: %d\n", failCount)
+    codes := make([]string, 0, len(failuresByCode))
+    for code := range failuresByCode {
+        codes = append(codes, code)
+    }
+    sort.Strings(codes)
+    for _, code := range codes {
+        fmt.Printf(" - %s: %d\n", code, failuresByCode[code])
+    }
+    fmt.Printf("Total Duration of Successful Tasks: %.2f seconds\n", totalDuration)
+    if config.WarmUp > 0 {
+        fmt.Printf("Statistics without the first %d tasks (warm-up), on %d tasks\n", config.WarmUp, durationStats.Count)
+    }
+    printStats("Average Task Duration", durationStats, config.Confidence)
+    printStats("Average Wait for a Client", waitStats, config.Confidence)
+    fmt.Printf("Bandwidth (tasks per second): %.2f\n", bandwidth)
+    fmt.Printf("Average Energy per Task: UAV %.2f J, EC %.2f J\n", energyUAV, energyEC)
+    fmt.Printf("Transaction Confirmation Time: %.2f seconds\n", transactionConfirmationTime)
+    fmt.Printf("Consensus Time: %.2f seconds\n", consensusTime)
+    fmt.Printf("Blockchain Function Used: %s\n", strategy)
+    fmt.Printf("Blockchain Network: %s\n", network)
+    fmt.Printf("\n")
+    for i, timeAt := range timesAt {
+        fmt.Printf("Average time for first %d tasks: %.2f seconds\n", (i+1)*10, timeAt.Seconds())
+    }
+    fmt.Printf("=====================================\n")
+}
+
+// printStats displays the mean of a series of the summary with its confidence intervals and its percentiles
+func printStats(name string, s stats.Summary, level float64) {
+    fmt.Printf("%s: %.2f seconds (%g%% CI: %.2f, %.2f)\n", name, s.Mean, level*100, s.CI.Low, s.CI.High)
+    if s.Batches > 0 {
+        fmt.Printf(" - Batch Means %g%% CI (%d batches): %.2f, %.2f\n", level*100, s.Batches, s.BatchCI.Low, s.BatchCI.High)
+    }
+    fmt.Printf(" - Percentiles: p50 %.2f, p90 %.2f, p95 %.2f, p99 %.2f seconds\n", s.P50, s.P90, s.P95, s.P99)
+}
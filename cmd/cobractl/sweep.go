@@ -0,0 +1,193 @@
+package main
+
+import (
+    "encoding/csv"
+    "fmt"
+    "math/rand"
+    "os"
+    "path/filepath"
+
+    "github.com/RezanOscar/COBRA/ledger"
+    "github.com/RezanOscar/COBRA/metrics"
+    "github.com/RezanOscar/COBRA/scenario"
+    "github.com/RezanOscar/COBRA/stats"
+)
+
+// sweepMetrics are the results of the runs aggregated in the table of a sweep
+var sweepMetrics = []struct {
+    name  string
+    value func(result summary) float64
+}{
+    {"Success Rate (%)", func(result summary) float64 { return result.SuccessRate }},
+    {"Avg Task Duration (s)", func(result summary) float64 { return result.MeanTaskDuration }},
+    {"P95 Task Duration (s)", func(result summary) float64 { return result.P95TaskDuration }},
+    {"Avg Wait (s)", func(result summary) float64 { return result.MeanWait }},
+    {"Bandwidth (tasks/s)", func(result summary) float64 { return result.Bandwidth }},
+    {"Energy/Task (UAV) (J)", func(result summary) float64 { return result.EnergyPerTaskUAV }},
+    {"Energy/Task (EC) (J)", func(result summary) float64 { return result.EnergyPerTaskEC }},
+    {"UAV Battery Avg (%)", func(result summary) float64 { return result.UAVBattery }},
+    {"UAV Available", func(result summary) float64 { return float64(result.AvailableUAVs) }},
+    {"TaskShareUAV (%)", func(result summary) float64 { return result.TaskShareUAV }},
+}
+
+// sweep runs each configuration of the grid of the scenario with the seeds seed, seed+1 ... and writes the means
+// and the confidence intervals of the results in sweep_results.csv. The results of each run are in the directory
+// <configuration>/seed_<seed> of the output
+func (c *cli) sweep(args []string) error {
+    flags := c.flagSet("sweep")
+    scenarioPath := flags.String("scenario", "", "YAML or JSON file of the scenario and of its sweep, the other flags override its values")
+    c.scenario.RegisterFlags(flags)
+    c.scenario.RegisterSweepFlags(flags)
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    err = c.scenario.Resolve(*scenarioPath, c.global, flags)
+    if err != nil {
+        return err
+    }
+    grid, err := c.scenario.Grid()
+    if err != nil {
+        return err
+    }
+
+    m, err := c.startMetrics()
+    if err != nil {
+        return err
+    }
+
+    base := c.scenario
+    fleet := base.Fleet
+    if base.Mode == scenario.ModeFabric && fleet.EC+fleet.UAV+fleet.HAPS+fleet.LEO == 0 {
+        return fmt.Errorf("The fleet of the sweep has no device, it is registered before each run")
+    }
+
+    // The sweep is recorded with the results
+    scenarioFile, err := base.Save("sweep")
+    if err != nil {
+        return err
+    }
+    replications := base.Sweep.Replications
+    fmt.Printf("Sweep of %d configurations with %d replications, scenario saved in %s\n", len(grid), replications, scenarioFile)
+
+    results := make([][]summary, len(grid))
+    for i, configuration := range grid {
+        for r := 0; r < replications; r++ {
+            scn := configuration.Scenario
+            scn.Seed += int64(r)
+            scn.Output = filepath.Join(base.Output, configuration.Label(), fmt.Sprintf("seed_%d", scn.Seed))
+            fmt.Printf("Run %d/%d: %s with the seed %d\n", i*replications+r+1, len(grid)*replications, configuration.Label(), scn.Seed)
+
+            result, err := runReplication(scn, m)
+            if err != nil {
+                return fmt.Errorf("Run of %s with the seed %d failed: %w", configuration.Label(), scn.Seed, err)
+            }
+            results[i] = append(results[i], result)
+        }
+    }
+
+    csvFilename := filepath.Join(base.Output, "sweep_results.csv")
+    err = writeSweepToCSV(csvFilename, base.Sweep.Parameters, grid, results, base.Stats.Confidence)
+    if err != nil {
+        return err
+    }
+    printSweep(grid, results, base.Stats.Confidence)
+    fmt.Printf("Results of the sweep written in %s\n", csvFilename)
+    return nil
+}
+
+// runReplication runs a scenario of the sweep from a new fleet: the discrete-event simulation creates its fleet
+// and on the ledger all the data are deleted and the fleet of the scenario is registered again
+func runReplication(scn scenario.Scenario, m *metrics.Metrics) (summary, error) {
+    if scn.Mode == scenario.ModeDES {
+        return runDES(scn, m)
+    }
+
+    _, err := scn.Save("scenario_" + scn.Strategy)
+    if err != nil {
+        return summary{}, err
+    }
+    var result summary
+    err = withLedger(scn.Ledger, func(client ledger.LedgerClient) error {
+        _, err := client.Submit("DeleteAll", "all")
+        if err != nil {
+            return fmt.Errorf("Failed to reset the ledger: %w", err)
+        }
+        specs, err := fleetSpecs(scn.Fleet, rand.New(rand.NewSource(scn.Seed)))
+        if err != nil {
+            return err
+        }
+        registered := registerDevices(client, specs, false, m)
+        if registered != len(specs) {
+            return fmt.Errorf("Registered %d of the %d devices of the fleet", registered, len(specs))
+        }
+
+        result, err = runFabric(client, scn, m)
+        return err
+    })
+    return result, err
+}
+
+// aggregate returns the mean and the Student t confidence interval at level of a metric over the replications of
+// a configuration
+func aggregate(results []summary, value func(result summary) float64, level float64) stats.Interval {
+    values := make([]float64, len(results))
+    for i, result := range results {
+        values[i] = value(result)
+    }
+    return stats.CI(values, level)
+}
+
+// writeSweepToCSV writes a row by configuration with the values of its parameters and the mean and the confidence
+// interval of each metric
+func writeSweepToCSV(filename string, parameters scenario.Parameters, grid []scenario.Configuration, results [][]summary, level float64) error {
+    file, err := os.Create(filename)
+    if err != nil {
+        return fmt.Errorf("failed to create CSV file: %w", err)
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    defer writer.Flush()
+
+    var header []string
+    for _, parameter := range parameters {
+        header = append(header, parameter.Name)
+    }
+    header = append(header, "Replications")
+    for _, metric := range sweepMetrics {
+        header = append(header, metric.name+" Mean", metric.name+" CI Low", metric.name+" CI High")
+    }
+    err = writer.Write(header)
+    if err != nil {
+        return fmt.Errorf("failed to write CSV header: %w", err)
+    }
+
+    for i, configuration := range grid {
+        row := append([]string(nil), configuration.Values...)
+        row = append(row, fmt.Sprintf("%d", len(results[i])))
+        for _, metric := range sweepMetrics {
+            ci := aggregate(results[i], metric.value, level)
+            row = append(row, fmt.Sprintf("%.4f", ci.Mean), fmt.Sprintf("%.4f", ci.Low), fmt.Sprintf("%.4f", ci.High))
+        }
+        err := writer.Write(row)
+        if err != nil {
+            return fmt.Errorf("failed to write CSV row: %w", err)
+        }
+    }
+    return nil
+}
+
+// printSweep displays the mean and the confidence interval of the metrics of each configuration
+func printSweep(grid []scenario.Configuration, results [][]summary, level float64) {
+    fmt.Printf("=====================================\n")
+    fmt.Printf("Sweep Complete\n")
+    for i, configuration := range grid {
+        fmt.Printf("\nConfiguration %s (%d replications):\n", configuration.Label(), len(results[i]))
+        for _, metric := range sweepMetrics {
+            ci := aggregate(results[i], metric.value, level)
+            fmt.Printf(" - %s: %.2f (%g%% CI: %.2f, %.2f)\n", metric.name, ci.Mean, level*100, ci.Low, ci.High)
+        }
+    }
+    fmt.Printf("=====================================\n")
+}
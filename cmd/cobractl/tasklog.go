@@ -0,0 +1,113 @@
+package main
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "time"
+
+    "github.com/RezanOscar/COBRA/scenario"
+)
+
+// taskRecord is the outcome of a task in the task log
+type taskRecord struct {
+    TaskID        string    `json:"taskID"` // TxID of the successful attempt
+    TaskType      string    `json:"taskType"`
+    Strategy      string    `json:"strategy"`
+    DeviceID      string    `json:"deviceID"`
+    DeviceType    string    `json:"deviceType"`
+    Arrival       time.Time `json:"arrival"`
+    Submitted     time.Time `json:"submitted"`     // First attempt
+    LastSubmitted time.Time `json:"lastSubmitted"` // Last attempt
+    Committed     time.Time `json:"committed"`     // End of the last attempt
+    Attempts      int       `json:"attempts"`
+    Success       bool      `json:"success"`
+    ErrorCode     string    `json:"errorCode"`
+    BatteryLife   float64   `json:"batteryLife"`   // Battery of the device after the assignment
+}
+
+// taskLogHeader are the columns of the CSV task log, the keys of the JSONL one
+var taskLogHeader = []string{"taskID", "taskType", "strategy", "deviceID", "deviceType", "arrival", "submitted", "lastSubmitted", "committed", "attempts", "success", "errorCode", "batteryLife"}
+
+// taskLog writes a record by finished task in JSONL or CSV
+type taskLog struct {
+    path    string
+    file    *os.File
+    csv     *csv.Writer
+    encoder *json.Encoder
+}
+
+// newTaskLog creates the task log <name>.<format> in the output directory of the scenario, or returns nil without
+// task log
+func newTaskLog(scn scenario.Scenario, name string) (*taskLog, error) {
+    if scn.TaskLog == "" {
+        return nil, nil
+    }
+    l := &taskLog{path: filepath.Join(scn.Output, name+"."+scn.TaskLog)}
+    var err error
+    l.file, err = os.Create(l.path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create task log: %w", err)
+    }
+    if scn.TaskLog == scenario.TaskLogJSONL {
+        l.encoder = json.NewEncoder(l.file)
+        return l, nil
+    }
+
+    l.csv = csv.NewWriter(l.file)
+    err = l.csv.Write(taskLogHeader)
+    if err != nil {
+        l.file.Close()
+        return nil, fmt.Errorf("failed to write CSV header: %w", err)
+    }
+    return l, nil
+}
+
+// write adds the record of a task, it does nothing without task log
+func (l *taskLog) write(record taskRecord) error {
+    if l == nil {
+        return nil
+    }
+    if l.encoder != nil {
+        return l.encoder.Encode(record)
+    }
+    formatTime := func(t time.Time) string {
+        return t.Format(time.RFC3339Nano)
+    }
+    return l.csv.Write([]string{
+        record.TaskID,
+        record.TaskType,
+        record.Strategy,
+        record.DeviceID,
+        record.DeviceType,
+        formatTime(record.Arrival),
+        formatTime(record.Submitted),
+        formatTime(record.LastSubmitted),
+        formatTime(record.Committed),
+        strconv.Itoa(record.Attempts),
+        strconv.FormatBool(record.Success),
+        record.ErrorCode,
+        formatFloat(record.BatteryLife),
+    })
+}
+
+// close flushes the records and closes the file, it does nothing without task log or if the log is already closed
+func (l *taskLog) close() error {
+    if l == nil || l.file == nil {
+        return nil
+    }
+    file := l.file
+    l.file = nil
+    if l.csv != nil {
+        l.csv.Flush()
+        err := l.csv.Error()
+        if err != nil {
+            file.Close()
+            return fmt.Errorf("failed to write task log: %w", err)
+        }
+    }
+    return file.Close()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "github.com/RezanOscar/COBRA/chaincode"
+    "github.com/RezanOscar/COBRA/ledger"
+    "github.com/RezanOscar/COBRA/simulator"
+)
+
+// tasksList shows the tasks with an optional filter on the DeviceID, the TaskType or the Status
+func (c *cli) tasksList(args []string) error {
+    filter, err := c.parseFilter("tasks list", args)
+    if err != nil {
+        return err
+    }
+
+    return c.withClient(func(client ledger.LedgerClient) error {
+        payload, err := client.Evaluate("QueryAllTasks")
+        if err != nil {
+            return fmt.Errorf("Failed to query tasks: %w", err)
+        }
+        var tasks []chaincode.Task
+        err = json.Unmarshal(payload, &tasks)
+        if err != nil {
+            return fmt.Errorf("Failed to decode tasks: %w", err)
+        }
+        for _, task := range tasks {
+            if filter == "" || task.DeviceID == filter || task.TaskType == filter || task.Status == filter {
+                fmt.Printf("TaskID: %s, DeviceID: %s, TaskData: %s, TaskType: %s, EnergyCost: %.2f, ComputeCost: %.2f, EnergyConsumed: %.2f J, Status: %s\n",
+                    task.TaskID, task.DeviceID, task.TaskData, task.TaskType, task.EnergyCost, task.ComputeCost, task.EnergyConsumed, task.Status)
+            }
+        }
+        return nil
+    })
+}
+
+// tasksSubmit offloads tasks of a type with a model of the smart contract, the costs that are not set are the
+// ones of the task type in the workload of the scenario
+func (c *cli) tasksSubmit(args []string) error {
+    flags := c.flagSet("tasks submit")
+    flags.StringVar(&c.scenario.Strategy, "strategy", c.scenario.Strategy, "Offload model: function of the smart contract")
+    flags.Float64Var(&c.scenario.Lambda, "lambda", c.scenario.Lambda, "Lambda of TaskOffloadCobra")
+    flags.Float64Var(&c.scenario.Epsilon, "epsilon", c.scenario.Epsilon, "Epsilon of TaskOffloadCobra")
+    taskTypeName := flags.String("type", "UC", "Task type: IC, HRLLC, UC, MC, AIC or ISC")
+    energyCost := flags.Float64("energy", 0, "Energy cost of the task, 0 for the cost of the task type")
+    computeCost := flags.Float64("compute", 0, "Compute cost of the task, 0 for the cost of the task type")
+    count := flags.Int("count", 1, "Number of tasks")
+    taskData := flags.String("data", "", "Data of the task, random if empty")
+    requestID := flags.String("request-id", "", "Request ID of the task, the same request sent again returns the first assignment")
+    err := flags.Parse(args)
+    if err != nil {
+        return err
+    }
+    if flags.NArg() > 0 {
+        return fmt.Errorf("Usage: cobractl tasks submit [flags]")
+    }
+    if *count <= 0 {
+        return fmt.Errorf("The number of tasks must be positive, got %d", *count)
+    }
+    if *requestID != "" && *count > 1 {
+        return fmt.Errorf("The request ID is for one task, got %d tasks", *count)
+    }
+
+    taskType := simulator.TaskType{Name: *taskTypeName, EnergyCost: *energyCost, ComputeCost: *computeCost}
+    for _, workload := range c.scenario.TaskTypes() {
+        if workload.Name == taskType.Name {
+            if taskType.EnergyCost == 0 {
+                taskType.EnergyCost = workload.EnergyCost
+            }
+            if taskType.ComputeCost == 0 {
+                taskType.ComputeCost = workload.ComputeCost
+            }
+        }
+    }
+
+    m, err := c.startMetrics()
+    if err != nil {
+        return err
+    }
+    return c.withClient(func(client ledger.LedgerClient) error {
+        for i := 0; i < *count; i++ {
+            data := *taskData
+            var err error
+            if data == "" {
+                data, err = generateRandomString(8)
+                if err != nil {
+                    return fmt.Errorf("Failed to generate task data: %w", err)
+                }
+            }
+
+            m.TaskIssued(c.scenario.Strategy, taskType.Name)
+            start := time.Now()
+            payload, err := client.Submit(c.scenario.Strategy, offloadArgs(c.scenario, data, taskType, *requestID)...)
+            m.Attempt(c.scenario.Strategy, taskType.Name, time.Since(start))
+            if err != nil {
+                m.TaskDone(c.scenario.Strategy, taskType.Name, false, "", errorCode(err), 1, 0)
+                fmt.Printf("Failed to offload task %s: %s (%s)\n", data, errorCode(err), err)
+                continue
+            }
+            var assignment chaincode.Assignment
+            json.Unmarshal(payload, &assignment)
+            m.TaskDone(c.scenario.Strategy, taskType.Name, true, assignment.DeviceType, "", 1, 0)
+            if assignment.Duplicate {
+                fmt.Printf("Request %s already offloaded on %s %s (TxID %s)\n", *requestID, assignment.DeviceType, assignment.DeviceID, assignment.TaskID)
+                continue
+            }
+            fmt.Printf("Offloaded task %s type %s with %s on %s %s (TxID %s)\n", data, taskType.Name, c.scenario.Strategy, assignment.DeviceType, assignment.DeviceID, assignment.TaskID)
+        }
+        return nil
+    })
+}
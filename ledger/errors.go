@@ -0,0 +1,60 @@
+package ledger
+
+import (
+    "errors"
+    "strings"
+)
+
+// Classes of the errors of Submit that are not rejections of the smart contract
+const (
+    ErrMVCCConflict        = "MVCC_CONFLICT"        // Invalidated at the commit because a key read was changed by another transaction
+    ErrEndorsementMismatch = "ENDORSEMENT_MISMATCH" // The peers returned different results or the endorsement policy is not met
+    ErrTimeout             = "TIMEOUT"              // No answer in time, the transaction may be committed
+    ErrOther               = "OTHER"                // Network or SDK error
+)
+
+// Status of a submitted transaction in the ledger
+const (
+    StatusCommitted = "COMMITTED" // Valid in a block, its writes are in the world state
+    StatusInvalid   = "INVALID"   // In a block but invalidated, its writes are discarded
+    StatusUnknown   = "UNKNOWN"   // Not in the ledger
+)
+
+// TxError is an error of Submit with the TxID of the transaction, known once the transaction is created, to
+// check if it was committed after an ambiguous error like a timeout
+type TxError struct {
+    TxID string
+    Err  error
+}
+
+func (e *TxError) Error() string {
+    return e.Err.Error()
+}
+
+func (e *TxError) Unwrap() error {
+    return e.Err
+}
+
+// TxID returns the TxID of the transaction of an error of Submit, empty if the transaction was not created
+func TxID(err error) string {
+    var txError *TxError
+    if errors.As(err, &txError) {
+        return txError.TxID
+    }
+    return ""
+}
+
+// Classify returns the class of an error of Submit without code of the smart contract, from the messages of the
+// Fabric SDK
+func Classify(err error) string {
+    message := strings.ToLower(err.Error())
+    switch {
+    case strings.Contains(message, "mvcc_read_conflict") || strings.Contains(message, "phantom_read_conflict"):
+        return ErrMVCCConflict
+    case strings.Contains(message, "proposalresponsepayloads do not match") || strings.Contains(message, "endorsement_policy_failure") || strings.Contains(message, "endorsement mismatch"):
+        return ErrEndorsementMismatch
+    case strings.Contains(message, "timeout") || strings.Contains(message, "timed out") || strings.Contains(message, "deadline exceeded"):
+        return ErrTimeout
+    }
+    return ErrOther
+}
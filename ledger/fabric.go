@@ -0,0 +1,126 @@
+package ledger
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+
+    "github.com/hyperledger/fabric-protos-go/peer"
+    "github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+    fabledger "github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+    "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+    "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+    "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+)
+
+// Fabric sends the transactions to the peers of the channel with the Fabric SDK
+type Fabric struct {
+    sdk       *fabsdk.FabricSDK
+    client    *channel.Client
+    ledger    *fabledger.Client // Queries the blocks for the status of the transactions
+    chaincode string
+}
+
+// NewFabric initializes the SDK with the connection profile and creates the channel client of the user
+func NewFabric(cfg Config) (*Fabric, error) {
+    sdk, err := fabsdk.New(config.FromFile(cfg.ConfigPath))
+    if err != nil {
+        return nil, fmt.Errorf("Failed to create SDK: %w", err)
+    }
+
+    channelContext := sdk.ChannelContext(cfg.Channel, fabsdk.WithUser(cfg.User), fabsdk.WithOrg(cfg.Org))
+    client, err := channel.New(channelContext)
+    if err != nil {
+        sdk.Close()
+        return nil, fmt.Errorf("Failed to create channel client: %w", err)
+    }
+    ledgerClient, err := fabledger.New(channelContext)
+    if err != nil {
+        sdk.Close()
+        return nil, fmt.Errorf("Failed to create ledger client: %w", err)
+    }
+
+    return &Fabric{sdk: sdk, client: client, ledger: ledgerClient, chaincode: cfg.Chaincode}, nil
+}
+
+// Submit endorses the transaction, sends it to the orderer and waits for its commit. The errors after the
+// endorsement have the TxID of the transaction
+func (f *Fabric) Submit(fcn string, args ...string) ([]byte, error) {
+    response, err := f.client.Execute(f.request(fcn, args))
+    if err != nil {
+        if response.TransactionID != "" {
+            return nil, &TxError{TxID: string(response.TransactionID), Err: err}
+        }
+        return nil, err
+    }
+    return response.Payload, nil
+}
+
+// Evaluate queries a peer without sending the transaction to the orderer
+func (f *Fabric) Evaluate(fcn string, args ...string) ([]byte, error) {
+    response, err := f.client.Query(f.request(fcn, args))
+    if err != nil {
+        return nil, err
+    }
+    return response.Payload, nil
+}
+
+// Status looks for the transaction in the blocks of the channel with its validation code
+func (f *Fabric) Status(txID string) (string, error) {
+    transaction, err := f.ledger.QueryTransaction(fab.TransactionID(txID))
+    if err != nil {
+        if strings.Contains(strings.ToLower(err.Error()), "not found") {
+            return StatusUnknown, nil
+        }
+        return "", fmt.Errorf("Failed to query the transaction %s: %w", txID, err)
+    }
+    if transaction.GetValidationCode() != int32(peer.TxValidationCode_VALID) {
+        return StatusInvalid, nil
+    }
+    return StatusCommitted, nil
+}
+
+// Subscribe registers to the chaincode events of the committed blocks
+func (f *Fabric) Subscribe(filter string) (<-chan Event, func(), error) {
+    registration, ccEvents, err := f.client.RegisterChaincodeEvent(f.chaincode, filter)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to register for the events %s: %w", filter, err)
+    }
+
+    // The SDK closes ccEvents when the registration is removed, done stops a send that nobody reads
+    events := make(chan Event)
+    done := make(chan struct{})
+    go func() {
+        defer close(events)
+        for ccEvent := range ccEvents {
+            select {
+            case events <- Event{Name: ccEvent.EventName, Payload: ccEvent.Payload, TxID: ccEvent.TxID, BlockNumber: ccEvent.BlockNumber}:
+            case <-done:
+                return
+            }
+        }
+    }()
+
+    var once sync.Once
+    cancel := func() {
+        once.Do(func() {
+            close(done)
+            f.client.UnregisterChaincodeEvent(registration)
+        })
+    }
+    return events, cancel, nil
+}
+
+// Close closes the SDK
+func (f *Fabric) Close() error {
+    f.sdk.Close()
+    return nil
+}
+
+func (f *Fabric) request(fcn string, args []string) channel.Request {
+    request := channel.Request{ChaincodeID: f.chaincode, Fcn: fcn}
+    for _, arg := range args {
+        request.Args = append(request.Args, []byte(arg))
+    }
+    return request
+}
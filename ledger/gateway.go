@@ -0,0 +1,247 @@
+package ledger
+
+import (
+    "context"
+    "crypto/x509"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/hyperledger/fabric-gateway/pkg/client"
+    "github.com/hyperledger/fabric-gateway/pkg/identity"
+    "github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+    "github.com/hyperledger/fabric-protos-go-apiv2/peer"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/proto"
+)
+
+// Timeouts of the calls to the gateway, the commit status waits for the block of the transaction
+const (
+    evaluateTimeout     = 5 * time.Second
+    endorseTimeout      = 15 * time.Second
+    submitTimeout       = 5 * time.Second
+    commitStatusTimeout = time.Minute
+)
+
+// Gateway sends the transactions to the Fabric Gateway of one peer (Fabric 2.4+), the peer endorses them on the
+// other organizations and sends them to the orderer, no connection profile is needed
+type Gateway struct {
+    connection *grpc.ClientConn
+    gateway    *client.Gateway
+    network    *client.Network
+    contract   *client.Contract
+    channel    string
+    chaincode  string
+}
+
+// NewGateway connects to the gateway of the peer of Endpoint with TLS and the identity of the user, its
+// certificate CertPath and its private key KeyPath in the MSP Org
+func NewGateway(cfg Config) (*Gateway, error) {
+    if cfg.Endpoint == "" || cfg.TLSCert == "" || cfg.CertPath == "" || cfg.KeyPath == "" {
+        return nil, fmt.Errorf("The gateway backend needs the endpoint, the TLS certificate of the peer and the certificate and the key of the user")
+    }
+
+    tlsPEM, err := os.ReadFile(cfg.TLSCert)
+    if err != nil {
+        return nil, fmt.Errorf("Failed to read the TLS certificate: %w", err)
+    }
+    tlsCert, err := identity.CertificateFromPEM(tlsPEM)
+    if err != nil {
+        return nil, fmt.Errorf("Failed to parse the TLS certificate %s: %w", cfg.TLSCert, err)
+    }
+    certPool := x509.NewCertPool()
+    certPool.AddCert(tlsCert)
+    // grpc.NewClient is in grpc 1.63+, go.mod pins grpc and the gateway (Go 1.21+)
+    connection, err := grpc.NewClient(cfg.Endpoint, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, cfg.HostOverride)))
+    if err != nil {
+        return nil, fmt.Errorf("Failed to create the connection to %s: %w", cfg.Endpoint, err)
+    }
+
+    id, sign, err := newIdentity(cfg)
+    if err != nil {
+        connection.Close()
+        return nil, err
+    }
+    gw, err := client.Connect(id,
+        client.WithSign(sign),
+        client.WithClientConnection(connection),
+        client.WithEvaluateTimeout(evaluateTimeout),
+        client.WithEndorseTimeout(endorseTimeout),
+        client.WithSubmitTimeout(submitTimeout),
+        client.WithCommitStatusTimeout(commitStatusTimeout),
+    )
+    if err != nil {
+        connection.Close()
+        return nil, fmt.Errorf("Failed to connect to the gateway: %w", err)
+    }
+
+    network := gw.GetNetwork(cfg.Channel)
+    return &Gateway{
+        connection: connection,
+        gateway:    gw,
+        network:    network,
+        contract:   network.GetContract(cfg.Chaincode),
+        channel:    cfg.Channel,
+        chaincode:  cfg.Chaincode,
+    }, nil
+}
+
+// newIdentity reads the X.509 identity of the user and the signer of its private key, KeyPath can be the keystore
+// directory of the MSP with the key as its only file
+func newIdentity(cfg Config) (*identity.X509Identity, identity.Sign, error) {
+    certPEM, err := os.ReadFile(cfg.CertPath)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to read the certificate of the user: %w", err)
+    }
+    cert, err := identity.CertificateFromPEM(certPEM)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to parse the certificate %s: %w", cfg.CertPath, err)
+    }
+    id, err := identity.NewX509Identity(cfg.Org, cert)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to create the identity of the user: %w", err)
+    }
+
+    keyPath := cfg.KeyPath
+    if info, err := os.Stat(keyPath); err == nil && info.IsDir() {
+        files, err := os.ReadDir(keyPath)
+        if err != nil || len(files) == 0 {
+            return nil, nil, fmt.Errorf("No private key in the keystore %s", keyPath)
+        }
+        keyPath = filepath.Join(keyPath, files[0].Name())
+    }
+    keyPEM, err := os.ReadFile(keyPath)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to read the private key: %w", err)
+    }
+    key, err := identity.PrivateKeyFromPEM(keyPEM)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to parse the private key %s: %w", keyPath, err)
+    }
+    sign, err := identity.NewPrivateKeySign(key)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to create the signer of the user: %w", err)
+    }
+    return id, sign, nil
+}
+
+// Submit endorses the transaction, sends it to the orderer and waits for its commit status. The TxID is known
+// before the endorsement, so all the errors have it
+func (g *Gateway) Submit(fcn string, args ...string) ([]byte, error) {
+    proposal, err := g.contract.NewProposal(fcn, client.WithArguments(args...))
+    if err != nil {
+        return nil, fmt.Errorf("Failed to create the proposal of %s: %w", fcn, err)
+    }
+    txID := proposal.TransactionID()
+
+    transaction, err := proposal.Endorse()
+    if err != nil {
+        return nil, &TxError{TxID: txID, Err: gatewayError(err)}
+    }
+    commit, err := transaction.Submit()
+    if err != nil {
+        return nil, &TxError{TxID: txID, Err: gatewayError(err)}
+    }
+    commitStatus, err := commit.Status()
+    if err != nil {
+        return nil, &TxError{TxID: txID, Err: gatewayError(err)}
+    }
+    if !commitStatus.Successful {
+        // The name of the validation code (MVCC_READ_CONFLICT ...) is classified by Classify
+        return nil, &TxError{TxID: txID, Err: fmt.Errorf("Transaction %s failed to commit with status code %d (%s)", txID, int32(commitStatus.Code), commitStatus.Code)}
+    }
+    return transaction.Result(), nil
+}
+
+// Evaluate queries the gateway peer without sending the transaction to the orderer
+func (g *Gateway) Evaluate(fcn string, args ...string) ([]byte, error) {
+    payload, err := g.contract.EvaluateTransaction(fcn, args...)
+    if err != nil {
+        return nil, gatewayError(err)
+    }
+    return payload, nil
+}
+
+// Status looks for the transaction in the blocks of the channel with the system chaincode qscc
+func (g *Gateway) Status(txID string) (string, error) {
+    payload, err := g.network.GetContract("qscc").EvaluateTransaction("GetTransactionByID", g.channel, txID)
+    if err != nil {
+        message := strings.ToLower(gatewayError(err).Error())
+        if strings.Contains(message, "not found") || strings.Contains(message, "no such transaction") {
+            return StatusUnknown, nil
+        }
+        return "", fmt.Errorf("Failed to query the transaction %s: %w", txID, err)
+    }
+
+    var transaction peer.ProcessedTransaction
+    if err := proto.Unmarshal(payload, &transaction); err != nil {
+        return "", fmt.Errorf("Failed to decode the transaction %s: %w", txID, err)
+    }
+    if transaction.GetValidationCode() != int32(peer.TxValidationCode_VALID) {
+        return StatusInvalid, nil
+    }
+    return StatusCommitted, nil
+}
+
+// Subscribe streams the chaincode events of the next blocks from the gateway, the events whose name does not match
+// the filter are dropped
+func (g *Gateway) Subscribe(filter string) (<-chan Event, func(), error) {
+    pattern, err := regexp.Compile(filter)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Invalid event filter %s: %w", filter, err)
+    }
+
+    ctx, stop := context.WithCancel(context.Background())
+    ccEvents, err := g.network.ChaincodeEvents(ctx, g.chaincode)
+    if err != nil {
+        stop()
+        return nil, nil, fmt.Errorf("Failed to stream the events %s: %w", filter, gatewayError(err))
+    }
+
+    // The gateway closes ccEvents when the context is cancelled
+    events := make(chan Event)
+    go forwardEvents(ctx, ccEvents, pattern, events)
+    return events, stop, nil
+}
+
+// forwardEvents decodes the chaincode events whose name matches the pattern until ccEvents is closed or the context
+// is cancelled, then closes events
+func forwardEvents(ctx context.Context, ccEvents <-chan *client.ChaincodeEvent, pattern *regexp.Regexp, events chan<- Event) {
+    defer close(events)
+    for ccEvent := range ccEvents {
+        if !pattern.MatchString(ccEvent.EventName) {
+            continue
+        }
+        select {
+        case events <- Event{Name: ccEvent.EventName, Payload: ccEvent.Payload, TxID: ccEvent.TransactionID, BlockNumber: ccEvent.BlockNumber}:
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+// Close closes the gateway and its connection
+func (g *Gateway) Close() error {
+    g.gateway.Close()
+    return g.connection.Close()
+}
+
+// gatewayError adds the messages of the peers to an error of the gateway: the errors of the smart contract, with
+// their JSON payload, are in the details of the gRPC status and not in its message
+func gatewayError(err error) error {
+    var messages []string
+    for _, detail := range status.Convert(err).Details() {
+        if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+            messages = append(messages, fmt.Sprintf("%s (%s): %s", errorDetail.GetAddress(), errorDetail.GetMspId(), errorDetail.GetMessage()))
+        }
+    }
+    if len(messages) == 0 {
+        return err
+    }
+    return fmt.Errorf("%w: %s", err, strings.Join(messages, "; "))
+}
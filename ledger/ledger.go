@@ -0,0 +1,124 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Client of the ledger used by the simulation and the tools of the COBRA framework
+//
+// version : 1.2
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - LedgerClient submits and evaluates the transactions of the smart contract and
+//      subscribes to its events, whatever the backend
+//      - fabric.go : Fabric SDK backend, the transactions are sent to the peers
+//      - gateway.go : Fabric Gateway backend (Fabric 2.4+), the transactions are sent to the
+//      gateway of one peer with the identity of the user read from its files, without the
+//      legacy SDK and its connection profile
+//      - memory.go : in-memory backend, the SmartContract runs in the process on a memstub
+//      world state, for the development and the tests without a Fabric network
+//      - errors.go : classes of the errors of the transactions (MVCC conflict, endorsement
+//      mismatch, timeout) and status of a transaction after an ambiguous error
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package ledger
+
+import (
+    "flag"
+    "fmt"
+
+    // Before the protos of the SDK and of the gateway are registered
+    _ "github.com/RezanOscar/COBRA/ledger/protoconflict"
+)
+
+// LedgerClient sends the transactions of the smart contract, the arguments are the strings given to the
+// contract and the errors of the contract keep their JSON payload {"code": ..., "message": ...}
+type LedgerClient interface {
+    // Submit runs a transaction that is committed in the ledger and returns its result
+    Submit(fcn string, args ...string) ([]byte, error)
+
+    // Evaluate runs a transaction that only reads the ledger and returns its result
+    Evaluate(fcn string, args ...string) ([]byte, error)
+
+    // Status returns if a submitted transaction is committed, invalidated or not in the ledger, the errors of
+    // Submit have the TxID of the transaction (see TxID)
+    Status(txID string) (string, error)
+
+    // Subscribe receives the events of the smart contract whose name matches the regular expression
+    // filter until cancel is called
+    Subscribe(filter string) (events <-chan Event, cancel func(), err error)
+
+    // Close releases the connection, the memory backend saves its world state
+    Close() error
+}
+
+// Event is an event set by a transaction of the smart contract
+type Event struct {
+    Name        string
+    Payload     []byte
+    TxID        string
+    BlockNumber uint64 // 0 on the memory backend
+}
+
+// Backends of the ledger client
+const (
+    BackendFabric  = "fabric"
+    BackendGateway = "gateway"
+    BackendMemory  = "memory"
+)
+
+// Config selects the backend and the identity of the client
+type Config struct {
+    Backend      string `json:"backend" yaml:"backend"`                               // fabric, gateway or memory
+    ConfigPath   string `json:"config" yaml:"config"`                                 // Connection profile of the Fabric SDK
+    Channel      string `json:"channel" yaml:"channel"`
+    Chaincode    string `json:"chaincode" yaml:"chaincode"`
+    User         string `json:"user" yaml:"user"`
+    Org          string `json:"org" yaml:"org"`                                       // Organization of the user, also the MSP of the transactions on the memory backend and of the identity on the gateway
+    StatePath    string `json:"state,omitempty" yaml:"state,omitempty"`               // File of the world state of the memory backend, empty to keep it only in the process
+    Endpoint     string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`         // Address of the peer of the gateway
+    TLSCert      string `json:"tlsCert,omitempty" yaml:"tlsCert,omitempty"`           // CA certificate of the TLS of the peer of the gateway
+    HostOverride string `json:"hostOverride,omitempty" yaml:"hostOverride,omitempty"` // Name of the peer in its TLS certificate, when the endpoint is another address
+    CertPath     string `json:"cert,omitempty" yaml:"cert,omitempty"`                 // Certificate of the user on the gateway (signcerts of its MSP)
+    KeyPath      string `json:"key,omitempty" yaml:"key,omitempty"`                   // Private key of the user on the gateway, or the keystore directory of its MSP
+}
+
+// DefaultConfig is the network of the README: the chaincode cobra_algo on the channel channelcoop
+func DefaultConfig() Config {
+    return Config{
+        Backend:    BackendFabric,
+        ConfigPath: "cobra-config.yaml",
+        Channel:    "channelcoop",
+        Chaincode:  "cobra_algo",
+        User:       "Admin",
+        Org:        "Provider1MSP",
+        Endpoint:   "peer0.pro1.research-network.com:7051",
+    }
+}
+
+// RegisterFlags adds the flags of the configuration to a flag set, the current values are the defaults
+func (c *Config) RegisterFlags(flags *flag.FlagSet) {
+    flags.StringVar(&c.Backend, "backend", c.Backend, "Ledger backend: fabric to use the Fabric network with the SDK, gateway with the Fabric Gateway, memory to run the smart contract in the process")
+    flags.StringVar(&c.ConfigPath, "config", c.ConfigPath, "Connection profile of the Fabric SDK")
+    flags.StringVar(&c.Channel, "channel", c.Channel, "Channel of the smart contract")
+    flags.StringVar(&c.Chaincode, "chaincode", c.Chaincode, "Name of the chaincode")
+    flags.StringVar(&c.User, "user", c.User, "User of the transactions")
+    flags.StringVar(&c.Org, "org", c.Org, "Organization (MSP) of the user")
+    flags.StringVar(&c.StatePath, "state", c.StatePath, "JSON file of the world state of the memory backend, shared by the tools")
+    flags.StringVar(&c.Endpoint, "endpoint", c.Endpoint, "Address of the peer of the Fabric Gateway")
+    flags.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "CA certificate of the TLS of the peer of the gateway")
+    flags.StringVar(&c.HostOverride, "host-override", c.HostOverride, "Name of the peer in its TLS certificate, when the endpoint is another address")
+    flags.StringVar(&c.CertPath, "cert", c.CertPath, "Certificate of the user on the gateway")
+    flags.StringVar(&c.KeyPath, "key", c.KeyPath, "Private key of the user on the gateway, or the keystore directory")
+}
+
+// New connects to the backend of the configuration
+func New(config Config) (LedgerClient, error) {
+    switch config.Backend {
+    case BackendFabric:
+        return NewFabric(config)
+    case BackendGateway:
+        return NewGateway(config)
+    case BackendMemory:
+        return NewMemory(config)
+    }
+    return nil, fmt.Errorf("Unknown ledger backend %s, must be %s, %s or %s", config.Backend, BackendFabric, BackendGateway, BackendMemory)
+}
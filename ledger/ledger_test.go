@@ -0,0 +1,292 @@
+package ledger
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "path/filepath"
+    "strings"
+    "regexp"
+    "testing"
+
+    "github.com/hyperledger/fabric-gateway/pkg/client"
+
+    "github.com/RezanOscar/COBRA/chaincode"
+)
+
+func newTestMemory(t *testing.T, statePath string) *Memory {
+    t.Helper()
+    config := DefaultConfig()
+    config.Backend = BackendMemory
+    config.StatePath = statePath
+    client, err := New(config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return client.(*Memory)
+}
+
+func register(t *testing.T, client LedgerClient, deviceID string, deviceType string) {
+    t.Helper()
+    spec, _ := json.Marshal(chaincode.DeviceSpec{DeviceID: deviceID, DeviceType: deviceType})
+    _, err := client.Submit("RegisterDeviceJSON", string(spec), "false")
+    if err != nil {
+        t.Fatalf("Failed to register %s: %v", deviceID, err)
+    }
+}
+
+func queryTasks(t *testing.T, client LedgerClient) []chaincode.Task {
+    t.Helper()
+    payload, err := client.Evaluate("QueryAllTasks")
+    if err != nil {
+        t.Fatal(err)
+    }
+    var tasks []chaincode.Task
+    err = json.Unmarshal(payload, &tasks)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return tasks
+}
+
+func TestMemorySubmitEvaluate(t *testing.T) {
+    client := newTestMemory(t, "")
+    defer client.Close()
+    register(t, client, "0001", "EC")
+    register(t, client, "0002", "UAV")
+
+    _, err := client.Submit("TaskOffloadCobra", "data", "UC", "0.5", "0.9", "0.3", "0.7", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if tasks := queryTasks(t, client); len(tasks) != 1 || tasks[0].DeviceID == "" {
+        t.Errorf("Got tasks %+v, want 1 assigned task", tasks)
+    }
+
+    payload, err := client.Evaluate("GetNetworkStats")
+    if err != nil {
+        t.Fatal(err)
+    }
+    var stats chaincode.NetworkStats
+    json.Unmarshal(payload, &stats)
+    if stats.Devices != 2 {
+        t.Errorf("Got %d devices in the stats, want 2", stats.Devices)
+    }
+
+    // The writes of an evaluated transaction are discarded
+    _, err = client.Evaluate("TaskOffloadCobra", "data", "UC", "0.5", "0.9", "0.3", "0.7", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if tasks := queryTasks(t, client); len(tasks) != 1 {
+        t.Errorf("Got %d tasks after an evaluation, want 1", len(tasks))
+    }
+}
+
+func TestMemoryErrors(t *testing.T) {
+    client := newTestMemory(t, "")
+    defer client.Close()
+
+    // The errors of the contract keep their code
+    _, err := client.Submit("TaskOffloadRandom", "data", "UC", "0.5", "0.9", "")
+    var contractError *chaincode.ContractError
+    if !errors.As(err, &contractError) || contractError.Code != chaincode.ErrNoCandidate {
+        t.Errorf("Got error %v, want NO_CANDIDATE", err)
+    }
+
+    calls := [][]string{
+        {"TaskOffloadUnknown"},
+        {"GetName"},                                     // Not a transaction
+        {"TaskOffloadRandom", "data", "UC"},             // Missing arguments
+        {"TaskOffloadRandom", "data", "UC", "x", "0.9", ""}, // Not a number
+        {"RegisterDeviceJSON", "{}", "maybe"},           // Not a bool
+    }
+    for _, call := range calls {
+        _, err := client.Submit(call[0], call[1:]...)
+        if err == nil {
+            t.Errorf("No error for %v", call)
+        }
+    }
+}
+
+func TestStatus(t *testing.T) {
+    client := newTestMemory(t, "")
+    defer client.Close()
+    register(t, client, "0001", "UAV")
+
+    // A committed transaction is found with its TxID, a rejected one has a TxID but is not in the ledger
+    payload, err := client.Submit("TaskOffloadFirstAvailable", "data", "UC", "0.5", "0.9", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    var assignment chaincode.Assignment
+    json.Unmarshal(payload, &assignment)
+    if status, _ := client.Status(assignment.TaskID); status != StatusCommitted {
+        t.Errorf("Got status %s of the committed task, want %s", status, StatusCommitted)
+    }
+    _, err = client.Submit("TaskOffloadFirstAvailable", "data", "XR", "0.5", "0.9", "")
+    txID := TxID(err)
+    if txID == "" {
+        t.Fatalf("No TxID in the error %v", err)
+    }
+    if status, _ := client.Status(txID); status != StatusUnknown {
+        t.Errorf("Got status %s of the rejected task, want %s", status, StatusUnknown)
+    }
+
+    for message, want := range map[string]string{
+        "Transaction processing for endorser [peer0:7051]: TxValidationCode: MVCC_READ_CONFLICT": ErrMVCCConflict,
+        "Multiple errors occurred: ProposalResponsePayloads do not match":                       ErrEndorsementMismatch,
+        "request timed out or been cancelled":                                                   ErrTimeout,
+        "context deadline exceeded":                                                             ErrTimeout,
+        "connection refused":                                                                    ErrOther,
+    } {
+        if got := Classify(&TxError{TxID: "tx", Err: errors.New(message)}); got != want {
+            t.Errorf("Got class %s for %q, want %s", got, message, want)
+        }
+    }
+}
+
+func TestMemoryState(t *testing.T) {
+    statePath := filepath.Join(t.TempDir(), "ledger.json")
+
+    // Like the tools, each client runs in its own session on the state file
+    client := newTestMemory(t, statePath)
+    register(t, client, "0001", "EC")
+    client.Submit("TaskOffloadFirstAvailable", "data", "UC", "0.5", "0.9", "")
+    err := client.Close()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    client = newTestMemory(t, statePath)
+    defer client.Close()
+    _, err = client.Submit("TaskOffloadFirstAvailable", "data", "UC", "0.5", "0.9", "")
+    if err != nil {
+        t.Fatalf("The device of the previous session is missing: %v", err)
+    }
+    if tasks := queryTasks(t, client); len(tasks) != 2 || tasks[0].TaskID == tasks[1].TaskID {
+        t.Errorf("Got tasks %+v, want the tasks of both sessions", tasks)
+    }
+}
+
+func TestMemorySubscribe(t *testing.T) {
+    client := newTestMemory(t, "")
+    defer client.Close()
+    register(t, client, "0001", "EC")
+
+    _, _, err := client.Subscribe("(")
+    if err == nil {
+        t.Errorf("No error for an invalid filter")
+    }
+
+    events, cancel, err := client.Subscribe("^Task")
+    if err != nil {
+        t.Fatal(err)
+    }
+    completions, cancelCompletions, err := client.Subscribe("^TaskCompleted$")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer cancelCompletions()
+
+    // The events of the smart contract are received after the commit of the transactions
+    payload, err := client.Submit("TaskOffloadFirstAvailable", "data", "UC", "0.5", "0.9", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    var assignment chaincode.Assignment
+    json.Unmarshal(payload, &assignment)
+    event := <-events
+    var task chaincode.Task
+    json.Unmarshal(event.Payload, &task)
+    if event.Name != chaincode.EventTaskAssigned || event.TxID != assignment.TaskID || task.TaskID != assignment.TaskID || task.DeviceID != "0001" {
+        t.Errorf("Got event %+v for the assignment %+v", event, assignment)
+    }
+
+    _, err = client.Submit("CompleteTask", assignment.TaskID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, received := range []<-chan Event{events, completions} {
+        event = <-received
+        json.Unmarshal(event.Payload, &task)
+        if event.Name != chaincode.EventTaskCompleted || task.TaskID != assignment.TaskID || task.Status != "Completed" {
+            t.Errorf("Got event %+v for the completion", event)
+        }
+    }
+    select {
+    case event := <-completions:
+        t.Errorf("Got the event %+v not matching the filter", event)
+    default:
+    }
+
+    cancel()
+    cancel()
+    if _, ok := <-events; ok {
+        t.Errorf("The events are not closed by cancel")
+    }
+}
+
+func TestNewUnknownBackend(t *testing.T) {
+    config := DefaultConfig()
+    config.Backend = "ethereum"
+    _, err := New(config)
+    if err == nil {
+        t.Errorf("No error for an unknown backend")
+    }
+}
+
+func TestGatewayEvents(t *testing.T) {
+    // Events of the blocks streamed by the gateway, only the tasks completed are forwarded
+    ccEvents := make(chan *client.ChaincodeEvent, 3)
+    ccEvents <- &client.ChaincodeEvent{BlockNumber: 7, TransactionID: "tx1", ChaincodeName: "cobra_algo", EventName: chaincode.EventTaskAssigned, Payload: []byte(`{"taskID": "tx1"}`)}
+    ccEvents <- &client.ChaincodeEvent{BlockNumber: 8, TransactionID: "tx2", ChaincodeName: "cobra_algo", EventName: chaincode.EventTaskCompleted, Payload: []byte(`{"taskID": "tx1"}`)}
+    close(ccEvents)
+
+    events := make(chan Event)
+    go forwardEvents(context.Background(), ccEvents, regexp.MustCompile("Completed$"), events)
+    event := <-events
+    if event.Name != chaincode.EventTaskCompleted || event.TxID != "tx2" || event.BlockNumber != 8 || string(event.Payload) != `{"taskID": "tx1"}` {
+        t.Errorf("Got event %+v", event)
+    }
+    if event, ok := <-events; ok {
+        t.Errorf("Got event %+v not matching the filter", event)
+    }
+
+    // Cancelled while nobody reads the events
+    ctx, cancel := context.WithCancel(context.Background())
+    blocked := make(chan *client.ChaincodeEvent, 1)
+    blocked <- &client.ChaincodeEvent{EventName: chaincode.EventTaskAssigned}
+    events = make(chan Event)
+    done := make(chan struct{})
+    go func() {
+        forwardEvents(ctx, blocked, regexp.MustCompile(".*"), events)
+        close(done)
+    }()
+    cancel()
+    <-done
+    if _, ok := <-events; ok {
+        t.Errorf("The events are not closed by the cancellation")
+    }
+}
+
+func TestGatewayConfig(t *testing.T) {
+    // The gateway needs the files of the identity, the errors are given before any connection
+    config := DefaultConfig()
+    config.Backend = BackendGateway
+    if _, err := New(config); err == nil {
+        t.Errorf("No error for a gateway without TLS certificate nor identity")
+    }
+    config.TLSCert = filepath.Join(t.TempDir(), "ca.crt")
+    config.CertPath = "cert.pem"
+    config.KeyPath = "keystore"
+    if _, err := New(config); err == nil || !strings.Contains(err.Error(), "TLS certificate") {
+        t.Errorf("Got %v for a missing TLS certificate", err)
+    }
+
+    // The errors without details are not changed
+    err := errors.New(`chaincode response 500, {"code":"NO_CANDIDATE"}`)
+    if gatewayError(err) != err {
+        t.Errorf("Got %v, want the error unchanged", gatewayError(err))
+    }
+}
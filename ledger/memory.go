@@ -0,0 +1,257 @@
+package ledger
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "reflect"
+    "regexp"
+    "sort"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+    "github.com/RezanOscar/COBRA/chaincode"
+    "github.com/RezanOscar/COBRA/memstub"
+)
+
+// eventBuffer is the number of events kept for a subscriber that does not read them, the next ones are dropped
+// so a transaction is never blocked by a subscriber
+const eventBuffer = 100
+
+// Memory runs the SmartContract in the process on a memstub world state, the transactions are committed one
+// at a time at the time of the call. The state is only shared by the tools through the file of StatePath
+type Memory struct {
+    mu          sync.Mutex
+    stub        *memstub.Stub
+    contract    *chaincode.SmartContract
+    mspID       string
+    statePath   string
+    subscribers map[int]*subscriber
+    nextID      int
+    committed   map[string]bool // TxIDs of the transactions committed by the process
+}
+
+// subscriber receives the events whose name matches its filter
+type subscriber struct {
+    filter *regexp.Regexp
+    events chan Event
+}
+
+// memoryState is the JSON file of the world state, the values of the keys are the JSON documents of the contract
+type memoryState struct {
+    Transactions int               `json:"transactions"`
+    State        map[string]string `json:"state"`
+}
+
+// NewMemory creates the world state, loaded from StatePath if the file exists
+func NewMemory(config Config) (*Memory, error) {
+    m := &Memory{
+        stub:        memstub.NewStub(),
+        contract:    new(chaincode.SmartContract),
+        mspID:       config.Org,
+        statePath:   config.StatePath,
+        subscribers: make(map[int]*subscriber),
+        committed:   make(map[string]bool),
+    }
+    if m.statePath == "" {
+        return m, nil
+    }
+
+    data, err := os.ReadFile(m.statePath)
+    if os.IsNotExist(err) {
+        return m, nil // Created at Close
+    }
+    if err != nil {
+        return nil, fmt.Errorf("Failed to read the world state: %w", err)
+    }
+    var saved memoryState
+    err = json.Unmarshal(data, &saved)
+    if err != nil {
+        return nil, fmt.Errorf("Failed to decode the world state %s: %w", m.statePath, err)
+    }
+    state := make(map[string][]byte, len(saved.State))
+    for key, value := range saved.State {
+        state[key] = []byte(value)
+    }
+    m.stub.Restore(state, saved.Transactions)
+    return m, nil
+}
+
+// Submit runs the transaction and commits its writes if it succeeds, its events are then sent to the subscribers
+func (m *Memory) Submit(fcn string, args ...string) ([]byte, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    ctx := m.stub.Begin(time.Now(), m.mspID)
+    txID := ctx.GetStub().GetTxID()
+    payload, err := m.invoke(ctx, fcn, args)
+    if err != nil {
+        m.stub.Rollback()
+        return nil, &TxError{TxID: txID, Err: err}
+    }
+    m.committed[txID] = true
+    m.publish(m.stub.Commit(), txID)
+    return payload, nil
+}
+
+// Status is committed for the transactions committed by the process, a rejected transaction is not in the ledger
+// like on Fabric where it is not sent to the orderer
+func (m *Memory) Status(txID string) (string, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    if m.committed[txID] {
+        return StatusCommitted, nil
+    }
+    return StatusUnknown, nil
+}
+
+// Evaluate runs the transaction and discards its writes
+func (m *Memory) Evaluate(fcn string, args ...string) ([]byte, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    ctx := m.stub.Begin(time.Now(), m.mspID)
+    defer m.stub.Rollback()
+    return m.invoke(ctx, fcn, args)
+}
+
+// Subscribe receives the events of the next committed transactions
+func (m *Memory) Subscribe(filter string) (<-chan Event, func(), error) {
+    pattern, err := regexp.Compile(filter)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Invalid event filter %s: %w", filter, err)
+    }
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    id := m.nextID
+    m.nextID++
+    sub := &subscriber{filter: pattern, events: make(chan Event, eventBuffer)}
+    m.subscribers[id] = sub
+
+    cancel := func() {
+        m.mu.Lock()
+        defer m.mu.Unlock()
+        if _, ok := m.subscribers[id]; ok {
+            delete(m.subscribers, id)
+            close(sub.events)
+        }
+    }
+    return sub.events, cancel, nil
+}
+
+// Close ends the subscriptions and saves the world state in StatePath
+func (m *Memory) Close() error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    for id, sub := range m.subscribers {
+        delete(m.subscribers, id)
+        close(sub.events)
+    }
+    if m.statePath == "" {
+        return nil
+    }
+
+    state, transactions := m.stub.Snapshot()
+    saved := memoryState{Transactions: transactions, State: make(map[string]string, len(state))}
+    for key, value := range state {
+        saved.State[key] = string(value)
+    }
+    data, err := json.Marshal(saved)
+    if err != nil {
+        return fmt.Errorf("Failed to encode the world state: %w", err)
+    }
+    err = os.WriteFile(m.statePath, data, 0644)
+    if err != nil {
+        return fmt.Errorf("Failed to save the world state: %w", err)
+    }
+    return nil
+}
+
+// publish sends the events of a committed transaction in the order of their names
+func (m *Memory) publish(events map[string][]byte, txID string) {
+    names := make([]string, 0, len(events))
+    for name := range events {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    for _, name := range names {
+        for _, sub := range m.subscribers {
+            if !sub.filter.MatchString(name) {
+                continue
+            }
+            select {
+            case sub.events <- Event{Name: name, Payload: events[name], TxID: txID}:
+            default: // Buffer full, the event is dropped
+            }
+        }
+    }
+}
+
+var (
+    contextType = reflect.TypeOf((*contractapi.TransactionContextInterface)(nil)).Elem()
+    errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// invoke calls the function of the contract like contractapi: the arguments are parsed from the strings to the
+// types of the parameters and the returned value is encoded in JSON
+func (m *Memory) invoke(ctx contractapi.TransactionContextInterface, fcn string, args []string) ([]byte, error) {
+    method := reflect.ValueOf(m.contract).MethodByName(fcn)
+    if !method.IsValid() {
+        return nil, fmt.Errorf("Function %s not found in the smart contract", fcn)
+    }
+    methodType := method.Type()
+    if methodType.NumIn() == 0 || methodType.In(0) != contextType || methodType.NumOut() == 0 || methodType.Out(methodType.NumOut()-1) != errorType {
+        return nil, fmt.Errorf("Function %s is not a transaction of the smart contract", fcn)
+    }
+    if len(args) != methodType.NumIn()-1 {
+        return nil, fmt.Errorf("Function %s takes %d arguments, got %d", fcn, methodType.NumIn()-1, len(args))
+    }
+
+    in := []reflect.Value{reflect.ValueOf(ctx)}
+    for i, arg := range args {
+        value, err := parseArgument(arg, methodType.In(i+1))
+        if err != nil {
+            return nil, fmt.Errorf("Argument %d of %s: %w", i+1, fcn, err)
+        }
+        in = append(in, value)
+    }
+
+    out := method.Call(in)
+    if err, _ := out[len(out)-1].Interface().(error); err != nil {
+        return nil, err
+    }
+    if len(out) == 1 {
+        return nil, nil
+    }
+    return json.Marshal(out[0].Interface())
+}
+
+// parseArgument converts the string of an argument to the type of the parameter
+func parseArgument(arg string, paramType reflect.Type) (reflect.Value, error) {
+    var value interface{}
+    var err error
+    switch paramType.Kind() {
+    case reflect.String:
+        value = arg
+    case reflect.Float64:
+        value, err = strconv.ParseFloat(arg, 64)
+    case reflect.Int:
+        value, err = strconv.Atoi(arg)
+    case reflect.Bool:
+        value, err = strconv.ParseBool(arg)
+    default:
+        return reflect.Value{}, fmt.Errorf("Unsupported parameter type %s", paramType)
+    }
+    if err != nil {
+        return reflect.Value{}, fmt.Errorf("%s is not a %s", arg, paramType)
+    }
+    return reflect.ValueOf(value).Convert(paramType), nil
+}
@@ -0,0 +1,20 @@
+// Package protoconflict lets the Fabric SDK and the Fabric Gateway share a binary, the SDK registers the Fabric
+// messages with fabric-protos-go and the gateway registers the same messages with fabric-protos-go-apiv2, the
+// protobuf runtime panics on the second registration unless its conflict policy says otherwise.
+//
+// Since Go 1.21 the packages are initialized in the order of their import paths once their imports are
+// initialized, this package sorts before github.com/hyperledger and google.golang.org so its init runs before the
+// messages are registered. Import it for its side effect in every package that links both protos.
+package protoconflict
+
+import "os"
+
+// policyEnv is read by google.golang.org/protobuf on each registration conflict
+const policyEnv = "GOLANG_PROTOBUF_REGISTRATION_CONFLICT"
+
+func init() {
+    // The duplicated messages are the same protos, the messages of each Go package keep their own descriptors
+    if os.Getenv(policyEnv) == "" {
+        os.Setenv(policyEnv, "ignore")
+    }
+}
@@ -0,0 +1,131 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Load generator of the simulation on the ledger
+//
+// version : 1.1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - Closed loop: each of the clients sends its next task when the previous one is done,
+//      like the simulation of the paper with one client
+//      - Open loop: the tasks are sent at their arrival time whatever the completions, with at
+//      most Concurrency tasks in flight, the tasks arriving when all the clients are busy wait
+//      for a free client in the order of their arrival
+//      - The results are returned in the order of the completions with the arrival, the wait
+//      and the times of the last attempt of each task
+//      - process.go : arrival processes of the open loop (constant, Poisson, MMPP, diurnal and
+//      flash crowds)
+//      - trace.go : records of the task requests of a trace in CSV or JSONL, to replay them
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package loadgen
+
+import (
+    "sync"
+    "time"
+
+    "github.com/RezanOscar/COBRA/simulator"
+)
+
+// Task is a task of the load, At is its arrival time since the start of the run in the open loop
+type Task struct {
+    Index    int // Position of the task in the load
+    At       time.Duration
+    TaskType simulator.TaskType
+    TaskData string
+}
+
+// Result is the outcome of a task, Start and End are the times of its last attempt
+type Result struct {
+    Task        Task
+    Arrival     time.Time // Time the task arrived, in the closed loop the time a client took it
+    Issued      time.Time // Time of the first attempt, after the wait for a free client
+    Start       time.Time
+    End         time.Time
+    Success     bool
+    Attempts    int
+    ErrorCode   string    // Code of the last failure
+    TaskID      string    // TxID of the successful attempt
+    DeviceID    string    // Device of the task after a success
+    DeviceType  string
+    BatteryLife float64   // Battery of the device after the assignment
+}
+
+// Wait is the time the task waited for a free client
+func (r Result) Wait() time.Duration {
+    return r.Issued.Sub(r.Arrival)
+}
+
+// Sender sends a task with its retries and returns Start, End, Success, Attempts, ErrorCode and the assignment of
+// the task in the result, it is called by several goroutines at the same time
+type Sender func(task Task) Result
+
+// Config is the load of a run
+type Config struct {
+    Concurrency int  // Maximum number of tasks in flight, the clients of the closed loop
+    OpenLoop    bool // The tasks are sent at their arrival time At, otherwise as soon as a client is free
+}
+
+// Start sends the tasks in the background, in the open loop they are in the order of their arrival. The results are
+// sent in the order of the completions and the channel is closed after the last one
+func Start(config Config, tasks []Task, send Sender) <-chan Result {
+    if config.Concurrency <= 0 {
+        config.Concurrency = 1
+    }
+    // The results never block the clients, so a slow reader does not change the load
+    results := make(chan Result, len(tasks))
+    start := time.Now()
+
+    run := func(task Task, arrival time.Time) {
+        issued := time.Now()
+        result := send(task)
+        result.Task = task
+        result.Arrival = arrival
+        result.Issued = issued
+        results <- result
+    }
+
+    if !config.OpenLoop {
+        next := make(chan Task)
+        var wg sync.WaitGroup
+        for client := 0; client < config.Concurrency; client++ {
+            wg.Add(1)
+            go func() {
+                defer wg.Done()
+                for task := range next {
+                    run(task, time.Now())
+                }
+            }()
+        }
+        go func() {
+            for _, task := range tasks {
+                next <- task
+            }
+            close(next)
+            wg.Wait()
+            close(results)
+        }()
+        return results
+    }
+
+    go func() {
+        var wg sync.WaitGroup
+        sem := make(chan struct{}, config.Concurrency)
+        for _, task := range tasks {
+            arrival := start.Add(task.At)
+            time.Sleep(time.Until(arrival))
+
+            sem <- struct{}{} // Wait for a free client
+            wg.Add(1)
+            go func(task Task) {
+                defer wg.Done()
+                defer func() { <-sem }()
+                run(task, arrival)
+            }(task)
+        }
+        wg.Wait()
+        close(results)
+    }()
+    return results
+}
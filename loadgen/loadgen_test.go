@@ -0,0 +1,217 @@
+package loadgen
+
+import (
+    "math"
+    "math/rand"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// tracker is a Sender that takes duration and counts the tasks in flight
+type tracker struct {
+    mu          sync.Mutex
+    inFlight    int
+    maxInFlight int
+    duration    time.Duration
+}
+
+func (t *tracker) send(task Task) Result {
+    t.mu.Lock()
+    t.inFlight++
+    if t.inFlight > t.maxInFlight {
+        t.maxInFlight = t.inFlight
+    }
+    t.mu.Unlock()
+
+    result := Result{Start: time.Now(), Success: true, Attempts: 1}
+    time.Sleep(t.duration)
+    result.End = time.Now()
+
+    t.mu.Lock()
+    t.inFlight--
+    t.mu.Unlock()
+    return result
+}
+
+func makeTasks(count int, interval time.Duration) []Task {
+    tasks := make([]Task, count)
+    for i := range tasks {
+        tasks[i] = Task{Index: i, At: time.Duration(i) * interval}
+    }
+    return tasks
+}
+
+func collect(results <-chan Result) []Result {
+    var collected []Result
+    for result := range results {
+        collected = append(collected, result)
+    }
+    return collected
+}
+
+func TestClosedLoop(t *testing.T) {
+    sender := &tracker{duration: 20 * time.Millisecond}
+    start := time.Now()
+    results := collect(Start(Config{Concurrency: 4}, makeTasks(12, 0), sender.send))
+    elapsed := time.Since(start)
+
+    if len(results) != 12 || sender.maxInFlight != 4 {
+        t.Errorf("Got %d results with %d tasks in flight, want 12 with 4", len(results), sender.maxInFlight)
+    }
+    // 3 rounds of the 4 clients, not 12 tasks one after the other
+    if elapsed > 200*time.Millisecond {
+        t.Errorf("The clients are not concurrent, %s for 12 tasks of 20ms", elapsed)
+    }
+    seen := make(map[int]bool)
+    for _, result := range results {
+        seen[result.Task.Index] = true
+        if result.Wait() != 0 && result.Wait() > 5*time.Millisecond {
+            t.Errorf("A task of the closed loop waited %s", result.Wait())
+        }
+    }
+    if len(seen) != 12 {
+        t.Errorf("Got %d different tasks, want 12", len(seen))
+    }
+}
+
+func TestOpenLoop(t *testing.T) {
+    // A task every 10ms taking 50ms: about 5 tasks in flight, the arrivals do not wait for the completions
+    sender := &tracker{duration: 50 * time.Millisecond}
+    start := time.Now()
+    results := collect(Start(Config{Concurrency: 20, OpenLoop: true}, makeTasks(20, 10*time.Millisecond), sender.send))
+    elapsed := time.Since(start)
+
+    if len(results) != 20 || sender.maxInFlight < 3 || sender.maxInFlight > 7 {
+        t.Errorf("Got %d results with at most %d tasks in flight, want 20 with about 5", len(results), sender.maxInFlight)
+    }
+    if elapsed < 240*time.Millisecond || elapsed > 500*time.Millisecond {
+        t.Errorf("Got %s for the last arrival at 190ms and tasks of 50ms", elapsed)
+    }
+    for _, result := range results {
+        if result.Arrival.Sub(start) < result.Task.At || result.Wait() > 20*time.Millisecond {
+            t.Errorf("Task %d arrived at %s and waited %s, want %s", result.Task.Index, result.Arrival.Sub(start), result.Wait(), result.Task.At)
+        }
+    }
+}
+
+func TestOpenLoopSaturated(t *testing.T) {
+    // One client for a task every 10ms taking 30ms: the tasks wait for the client in the order of their arrival
+    sender := &tracker{duration: 30 * time.Millisecond}
+    results := collect(Start(Config{Concurrency: 1, OpenLoop: true}, makeTasks(6, 10*time.Millisecond), sender.send))
+
+    if sender.maxInFlight != 1 {
+        t.Errorf("Got %d tasks in flight, want 1", sender.maxInFlight)
+    }
+    for i, result := range results {
+        if result.Task.Index != i {
+            t.Errorf("Got task %d in position %d", result.Task.Index, i)
+        }
+    }
+    // The last task arrived at 50ms and is sent after the 5 first ones, at 150ms
+    if wait := results[5].Wait(); wait < 80*time.Millisecond {
+        t.Errorf("The last task waited %s, want about 100ms", wait)
+    }
+}
+
+// rateIn is the rate of arrivals in tasks per second between from and to
+func rateIn(arrivals []time.Duration, from time.Duration, to time.Duration) float64 {
+    count := 0
+    for _, at := range arrivals {
+        if at >= from && at < to {
+            count++
+        }
+    }
+    return float64(count) / (to - from).Seconds()
+}
+
+func TestProcesses(t *testing.T) {
+    rnd := rand.New(rand.NewSource(1))
+
+    poisson := Process{Kind: ProcessPoisson, Rate: 10}.Arrivals(10000, rnd)
+    if rate := float64(len(poisson)) / poisson[len(poisson)-1].Seconds(); math.Abs(rate-10) > 0.5 {
+        t.Errorf("Got a Poisson rate of %.2f, want 10", rate)
+    }
+
+    // Calm at 1 task/s for 90s and bursts at 50 tasks/s for 10s: 5.9 tasks/s on average
+    mmpp := Process{Kind: ProcessMMPP, Rate: 1, BurstRate: 50, CalmTime: 90 * time.Second, BurstTime: 10 * time.Second}.Arrivals(100000, rnd)
+    if rate := float64(len(mmpp)) / mmpp[len(mmpp)-1].Seconds(); rate < 4.5 || rate > 7.5 {
+        t.Errorf("Got an MMPP rate of %.2f, want about 5.9", rate)
+    }
+
+    diurnal := Process{Kind: ProcessDiurnal, Rate: 10, Amplitude: 0.8, Period: 100 * time.Second, Peak: 25 * time.Second}.Arrivals(20000, rnd)
+    peak, trough := rateIn(diurnal, 20*time.Second, 30*time.Second), rateIn(diurnal, 70*time.Second, 80*time.Second)
+    if peak < 15 || trough > 5 {
+        t.Errorf("Got a diurnal rate of %.2f at the peak and %.2f at the trough, want about 18 and 2", peak, trough)
+    }
+
+    // Without base rate the flash has no arrival after its end
+    flash := Process{Kind: ProcessFlash, Flashes: []Flash{{At: 10 * time.Second, Duration: 5 * time.Second, Rate: 100}}}.Arrivals(10000, rnd)
+    if len(flash) < 400 || len(flash) > 600 || flash[0] < 10*time.Second || flash[len(flash)-1] >= 15*time.Second {
+        t.Errorf("Got %d flash arrivals from %v to %v", len(flash), flash[0], flash[len(flash)-1])
+    }
+
+    constant := Process{Kind: ProcessConstant, Rate: 2}.Arrivals(3, rnd)
+    if constant[2] != time.Second {
+        t.Errorf("Got constant arrivals %v", constant)
+    }
+}
+
+func TestProcessValidate(t *testing.T) {
+    for _, process := range []Process{
+        {Kind: "burst", Rate: 1},
+        {Kind: ProcessPoisson},
+        {Kind: ProcessMMPP, Rate: 1, BurstRate: 10},
+        {Kind: ProcessDiurnal, Rate: 1, Amplitude: 2, Period: time.Hour},
+        {Kind: ProcessFlash, Rate: 1},
+    } {
+        if process.Validate() == nil {
+            t.Errorf("No error for %+v", process)
+        }
+    }
+}
+
+func TestReadTrace(t *testing.T) {
+    dir := t.TempDir()
+    csvPath := filepath.Join(dir, "trace.csv")
+    jsonlPath := filepath.Join(dir, "trace.jsonl")
+    os.WriteFile(csvPath, []byte(`timestamp,type,energyCost,computeCost,dataSize,origin
+1700000002.5,UC,0.5,0.9,200,sensor-1
+1700000000,HRLLC,1.1,1.9,,drone-3
+1700000001,XR,1,1,10,sensor-2
+1700000004,MC,abc,1.4,10,sensor-2
+`), 0644)
+    os.WriteFile(jsonlPath, []byte(`{"timestamp": "2024-01-01T00:00:02.5Z", "type": "UC", "energyCost": 0.5, "computeCost": 0.9, "dataSize": 200, "origin": "sensor-1"}
+{"timestamp": "2024-01-01T00:00:00Z", "type": "HRLLC", "energyCost": 1.1, "computeCost": 1.9, "origin": "drone-3"}
+
+{"timestamp": 12, "type": "XR", "energyCost": 1, "computeCost": 1}
+{"type": "MC", "energyCost": 0.9, "computeCost": 1.4}
+`), 0644)
+
+    for _, path := range []string{csvPath, jsonlPath} {
+        records, rejected, err := ReadTrace(path)
+        if err != nil {
+            t.Fatalf("%s: %v", path, err)
+        }
+        // The records are in the order of their timestamps
+        if len(records) != 2 || records[0].TaskType != "HRLLC" || records[0].At != 0 || records[1].At != 2500*time.Millisecond ||
+            records[1].Origin != "sensor-1" || records[1].DataSize != 200 || records[1].ComputeCost != 0.9 {
+            t.Errorf("%s: got records %+v", path, records)
+        }
+        if len(rejected) != 2 || !strings.Contains(rejected[0].Reason, "unknown task type") {
+            t.Errorf("%s: got rejections %+v", path, rejected)
+        }
+    }
+
+    os.WriteFile(csvPath, []byte("timestamp,type\n1,UC\n"), 0644)
+    if _, _, err := ReadTrace(csvPath); err == nil {
+        t.Errorf("No error for a trace without costs")
+    }
+    os.WriteFile(jsonlPath, []byte(`{"timestamp": 1, "type": "XR", "energyCost": 1, "computeCost": 1}`), 0644)
+    if _, rejected, err := ReadTrace(jsonlPath); err == nil || len(rejected) != 1 {
+        t.Errorf("Got %v and %v for a trace without valid record", err, rejected)
+    }
+}
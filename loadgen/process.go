@@ -0,0 +1,172 @@
+package loadgen
+
+import (
+    "fmt"
+    "math"
+    "math/rand"
+    "time"
+)
+
+// Kinds of arrival processes
+const (
+    ProcessConstant = "constant" // A task every 1/Rate second
+    ProcessPoisson  = "poisson"  // Poisson process of rate Rate
+    ProcessMMPP     = "mmpp"     // Markov-modulated Poisson process, calm at Rate and bursts at BurstRate
+    ProcessDiurnal  = "diurnal"  // Poisson process with a sinusoidal rate around Rate, at its max at Peak
+    ProcessFlash    = "flash"    // Poisson process of rate Rate with the extra rates of the flash crowds
+)
+
+// Processes are the kinds of arrival processes
+var Processes = []string{ProcessConstant, ProcessPoisson, ProcessMMPP, ProcessDiurnal, ProcessFlash}
+
+// Process is an arrival process of tasks, the rates are in tasks per second
+type Process struct {
+    Kind      string
+    Rate      float64
+    BurstRate float64       // Rate of the bursts of mmpp
+    CalmTime  time.Duration // Mean time between two bursts of mmpp
+    BurstTime time.Duration // Mean time of a burst of mmpp
+    Amplitude float64       // Variation of the rate of diurnal, between 0 and 1 of Rate
+    Period    time.Duration // Period of diurnal, 24h for a day
+    Peak      time.Duration // Time of the max of the rate of diurnal in the period
+    Flashes   []Flash
+}
+
+// Flash is a flash crowd, like the requests of a disaster response, adding Rate during Duration from At
+type Flash struct {
+    At       time.Duration
+    Duration time.Duration
+    Rate     float64
+}
+
+// Validate checks the parameters of the process
+func (p Process) Validate() error {
+    switch p.Kind {
+    case ProcessConstant, ProcessPoisson:
+        if p.Rate <= 0 {
+            return fmt.Errorf("The rate of %s must be positive", p.Kind)
+        }
+    case ProcessMMPP:
+        if p.Rate < 0 || p.BurstRate < 0 || p.Rate+p.BurstRate == 0 {
+            return fmt.Errorf("The rates of mmpp must not be negative and one must be positive")
+        }
+        if p.CalmTime <= 0 || p.BurstTime <= 0 {
+            return fmt.Errorf("The calm and burst times of mmpp must be positive")
+        }
+    case ProcessDiurnal:
+        if p.Rate <= 0 || p.Amplitude < 0 || p.Amplitude > 1 || p.Period <= 0 {
+            return fmt.Errorf("The rate and the period of diurnal must be positive and its amplitude between 0 and 1")
+        }
+    case ProcessFlash:
+        if p.Rate < 0 || len(p.Flashes) == 0 {
+            return fmt.Errorf("The rate of flash must not be negative and it needs flashes")
+        }
+        for _, flash := range p.Flashes {
+            if flash.At < 0 || flash.Duration <= 0 || flash.Rate <= 0 {
+                return fmt.Errorf("The flashes must start after 0 with a positive duration and rate")
+            }
+        }
+    default:
+        return fmt.Errorf("Unknown arrival process %s", p.Kind)
+    }
+    return nil
+}
+
+// Arrivals draws the arrival times of at most count tasks since the start, in order. A flash process of rate 0
+// has no arrival after its last flash
+func (p Process) Arrivals(count int, rnd *rand.Rand) []time.Duration {
+    arrivals := make([]time.Duration, 0, count)
+    add := func(t float64) {
+        arrivals = append(arrivals, time.Duration(t*float64(time.Second)))
+    }
+
+    switch p.Kind {
+    case ProcessConstant:
+        for i := 0; i < count; i++ {
+            add(float64(i) / p.Rate)
+        }
+
+    case ProcessPoisson:
+        t := 0.0
+        for len(arrivals) < count {
+            t += rnd.ExpFloat64() / p.Rate
+            add(t)
+        }
+
+    case ProcessMMPP:
+        // The arrivals of a state are a Poisson process, the times of the states are exponential
+        t := 0.0
+        burst := false
+        end := rnd.ExpFloat64() * p.CalmTime.Seconds()
+        for len(arrivals) < count {
+            rate := p.Rate
+            if burst {
+                rate = p.BurstRate
+            }
+            next := math.Inf(1)
+            if rate > 0 {
+                next = t + rnd.ExpFloat64()/rate
+            }
+            if next > end {
+                t = end
+                burst = !burst
+                mean := p.CalmTime
+                if burst {
+                    mean = p.BurstTime
+                }
+                end = t + rnd.ExpFloat64()*mean.Seconds()
+                continue
+            }
+            t = next
+            add(t)
+        }
+
+    case ProcessDiurnal, ProcessFlash:
+        // Thinning of a Poisson process at the max rate, an arrival is kept with the probability rate(t)/max
+        max := p.maxRate()
+        last := math.Inf(1)
+        if p.Kind == ProcessFlash && p.Rate == 0 {
+            last = 0
+            for _, flash := range p.Flashes {
+                last = math.Max(last, (flash.At + flash.Duration).Seconds())
+            }
+        }
+        t := 0.0
+        for len(arrivals) < count {
+            t += rnd.ExpFloat64() / max
+            if t > last {
+                break
+            }
+            if rnd.Float64()*max < p.rate(t) {
+                add(t)
+            }
+        }
+    }
+    return arrivals
+}
+
+// rate is the rate of a diurnal or flash process at the time t in seconds
+func (p Process) rate(t float64) float64 {
+    if p.Kind == ProcessDiurnal {
+        return p.Rate * (1 + p.Amplitude*math.Cos(2*math.Pi*(t-p.Peak.Seconds())/p.Period.Seconds()))
+    }
+    rate := p.Rate
+    for _, flash := range p.Flashes {
+        if t >= flash.At.Seconds() && t < (flash.At+flash.Duration).Seconds() {
+            rate += flash.Rate
+        }
+    }
+    return rate
+}
+
+// maxRate is a bound of the rate of a diurnal or flash process
+func (p Process) maxRate() float64 {
+    if p.Kind == ProcessDiurnal {
+        return p.Rate * (1 + p.Amplitude)
+    }
+    rate := p.Rate
+    for _, flash := range p.Flashes {
+        rate += flash.Rate
+    }
+    return rate
+}
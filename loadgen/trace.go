@@ -0,0 +1,226 @@
+package loadgen
+
+import (
+    "bufio"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/RezanOscar/COBRA/scheduler"
+)
+
+// Record is a task request of a trace, At is its time since the first request of the trace
+type Record struct {
+    Line        int // Line of the record in the file
+    Timestamp   time.Time
+    At          time.Duration
+    TaskType    string
+    EnergyCost  float64
+    ComputeCost float64
+    DataSize    float64 // Size of the data of the request, 0 if unknown
+    Origin      string  // Device or area sending the request
+}
+
+// Rejection is a record of a trace that is not replayed
+type Rejection struct {
+    Line   int
+    Reason string
+}
+
+// traceRecord is a record of a JSONL trace, the timestamp is a number of seconds or an RFC 3339 time
+type traceRecord struct {
+    Timestamp   interface{} `json:"timestamp"`
+    TaskType    string      `json:"type"`
+    EnergyCost  float64     `json:"energyCost"`
+    ComputeCost float64     `json:"computeCost"`
+    DataSize    float64     `json:"dataSize"`
+    Origin      string      `json:"origin"`
+}
+
+// ReadTrace reads the task requests of a trace, JSONL (one JSON object by line) if its extension is .jsonl and CSV
+// with a header otherwise. The columns of the CSV are timestamp, type, energyCost, computeCost and the optional
+// dataSize and origin. The records with an unknown task type or invalid values are rejected, the others are
+// returned in the order of their timestamps
+func ReadTrace(path string) ([]Record, []Rejection, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to read the trace: %w", err)
+    }
+    defer file.Close()
+
+    var records []Record
+    var rejected []Rejection
+    if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+        records, rejected, err = readJSONL(file)
+    } else {
+        records, rejected, err = readCSV(file)
+    }
+    if err != nil {
+        return nil, nil, fmt.Errorf("Failed to decode the trace %s: %w", path, err)
+    }
+    if len(records) == 0 {
+        return nil, rejected, fmt.Errorf("The trace %s has no valid record", path)
+    }
+
+    sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp.Before(records[j].Timestamp) })
+    for i := range records {
+        records[i].At = records[i].Timestamp.Sub(records[0].Timestamp)
+    }
+    return records, rejected, nil
+}
+
+// readCSV reads the records of a CSV trace
+func readCSV(reader io.Reader) ([]Record, []Rejection, error) {
+    csvReader := csv.NewReader(reader)
+    csvReader.FieldsPerRecord = -1
+    header, err := csvReader.Read()
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+    }
+    columns := make(map[string]int)
+    for i, name := range header {
+        columns[strings.ToLower(strings.TrimSpace(name))] = i
+    }
+    for _, name := range []string{"timestamp", "type", "energycost", "computecost"} {
+        if _, ok := columns[name]; !ok {
+            return nil, nil, fmt.Errorf("missing column %s in CSV header", name)
+        }
+    }
+
+    var records []Record
+    var rejected []Rejection
+    for line := 2; ; line++ {
+        row, err := csvReader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, nil, fmt.Errorf("failed to read CSV row: %w", err)
+        }
+        field := func(name string) string {
+            index, ok := columns[name]
+            if !ok || index >= len(row) {
+                return ""
+            }
+            return strings.TrimSpace(row[index])
+        }
+
+        record := Record{Line: line, TaskType: field("type"), Origin: field("origin")}
+        var problems []string
+        record.Timestamp, err = parseTimestamp(field("timestamp"))
+        if err != nil {
+            problems = append(problems, err.Error())
+        }
+        for _, number := range []struct {
+            name  string
+            value *float64
+        }{{"energycost", &record.EnergyCost}, {"computecost", &record.ComputeCost}, {"datasize", &record.DataSize}} {
+            text := field(number.name)
+            if text == "" && number.name == "datasize" {
+                continue
+            }
+            *number.value, err = strconv.ParseFloat(text, 64)
+            if err != nil {
+                problems = append(problems, fmt.Sprintf("invalid %s %q", number.name, text))
+            }
+        }
+        problems = append(problems, record.problems()...)
+        if len(problems) > 0 {
+            rejected = append(rejected, Rejection{Line: line, Reason: strings.Join(problems, ", ")})
+            continue
+        }
+        records = append(records, record)
+    }
+    return records, rejected, nil
+}
+
+// readJSONL reads the records of a JSONL trace, the empty lines are skipped
+func readJSONL(reader io.Reader) ([]Record, []Rejection, error) {
+    var records []Record
+    var rejected []Rejection
+    scanner := bufio.NewScanner(reader)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+    for line := 1; scanner.Scan(); line++ {
+        text := strings.TrimSpace(scanner.Text())
+        if text == "" {
+            continue
+        }
+
+        var decoded traceRecord
+        err := json.Unmarshal([]byte(text), &decoded)
+        if err != nil {
+            rejected = append(rejected, Rejection{Line: line, Reason: fmt.Sprintf("invalid JSON: %v", err)})
+            continue
+        }
+        record := Record{
+            Line:        line,
+            TaskType:    decoded.TaskType,
+            EnergyCost:  decoded.EnergyCost,
+            ComputeCost: decoded.ComputeCost,
+            DataSize:    decoded.DataSize,
+            Origin:      decoded.Origin,
+        }
+        var problems []string
+        switch timestamp := decoded.Timestamp.(type) {
+        case float64:
+            record.Timestamp = secondsToTime(timestamp)
+        case string:
+            record.Timestamp, err = parseTimestamp(timestamp)
+            if err != nil {
+                problems = append(problems, err.Error())
+            }
+        default:
+            problems = append(problems, "missing timestamp")
+        }
+        problems = append(problems, record.problems()...)
+        if len(problems) > 0 {
+            rejected = append(rejected, Rejection{Line: line, Reason: strings.Join(problems, ", ")})
+            continue
+        }
+        records = append(records, record)
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, nil, err
+    }
+    return records, rejected, nil
+}
+
+// problems returns the reasons to reject a decoded record
+func (r Record) problems() []string {
+    var problems []string
+    if !scheduler.ValidTaskType(r.TaskType) {
+        problems = append(problems, fmt.Sprintf("unknown task type %q", r.TaskType))
+    }
+    if r.EnergyCost <= 0 || r.ComputeCost <= 0 {
+        problems = append(problems, "the costs must be positive")
+    }
+    if r.DataSize < 0 {
+        problems = append(problems, "the data size must not be negative")
+    }
+    return problems
+}
+
+// parseTimestamp parses a number of seconds, like a Unix time, or an RFC 3339 time
+func parseTimestamp(text string) (time.Time, error) {
+    seconds, err := strconv.ParseFloat(text, 64)
+    if err == nil {
+        return secondsToTime(seconds), nil
+    }
+    timestamp, err := time.Parse(time.RFC3339Nano, text)
+    if err != nil {
+        return timestamp, fmt.Errorf("invalid timestamp %q", text)
+    }
+    return timestamp, nil
+}
+
+// secondsToTime converts a number of seconds since the Unix epoch
+func secondsToTime(seconds float64) time.Time {
+    return time.Unix(0, 0).Add(time.Duration(seconds * float64(time.Second)))
+}
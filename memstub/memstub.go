@@ -0,0 +1,224 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : In-memory world state to run the COBRA smart contract without a Fabric network
+//
+// version : 1.1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - Stub implements the calls of the smart contract on the ChaincodeStubInterface, the
+//      other calls panic
+//      - Like on a peer the writes of a transaction are only visible after its commit and the
+//      range queries are sorted with an exclusive end key
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package memstub
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "sort"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+    "github.com/hyperledger/fabric-chaincode-go/shim"
+    "github.com/hyperledger/fabric-contract-api-go/contractapi"
+    "github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+    "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Stub is an in-memory world state with one transaction running at a time
+type Stub struct {
+    shim.ChaincodeStubInterface // Not implemented calls
+
+    mu      sync.Mutex
+    state   map[string][]byte    // Committed state
+    writes  map[string][]byte    // Writes of the running transaction, nil for a delete
+    events  map[string][]byte    // Events set by the running transaction
+    txID    string
+    txTime  time.Time
+    txCount int
+}
+
+// Identity is the client identity of a transaction, only the MSP is used by the smart contract
+type Identity struct {
+    cid.ClientIdentity // Not implemented calls
+
+    MSPID string
+}
+
+// GetMSPID returns the MSP of the client
+func (i *Identity) GetMSPID() (string, error) {
+    if i.MSPID == "" {
+        return "", fmt.Errorf("No MSP for the client")
+    }
+    return i.MSPID, nil
+}
+
+// NewStub returns an empty world state
+func NewStub() *Stub {
+    return &Stub{state: make(map[string][]byte)}
+}
+
+// Begin starts a transaction at the time now and returns its context for the client of the MSP, the TxID is
+// the hex SHA-256 of the transaction number like the TxIDs of Fabric
+func (s *Stub) Begin(now time.Time, mspID string) contractapi.TransactionContextInterface {
+    s.txCount++
+    hash := sha256.Sum256([]byte(strconv.Itoa(s.txCount)))
+    s.txID = hex.EncodeToString(hash[:])
+    s.txTime = now
+    s.writes = make(map[string][]byte)
+    s.events = make(map[string][]byte)
+
+    ctx := new(contractapi.TransactionContext)
+    ctx.SetStub(s)
+    ctx.SetClientIdentity(&Identity{MSPID: mspID})
+    return ctx
+}
+
+// Commit writes the changes of the running transaction in the world state and returns its events
+func (s *Stub) Commit() map[string][]byte {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for key, value := range s.writes {
+        if value == nil {
+            delete(s.state, key)
+        } else {
+            s.state[key] = value
+        }
+    }
+    events := s.events
+    s.writes = nil
+    s.events = nil
+    return events
+}
+
+// Rollback discards the changes of the running transaction, like an endorsement that failed
+func (s *Stub) Rollback() {
+    s.writes = nil
+    s.events = nil
+}
+
+// Keys returns the sorted keys of the committed state
+func (s *Stub) Keys() []string {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    keys := make([]string, 0, len(s.state))
+    for key := range s.state {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+// Snapshot returns a copy of the committed state and the number of transactions
+func (s *Stub) Snapshot() (map[string][]byte, int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    state := make(map[string][]byte, len(s.state))
+    for key, value := range s.state {
+        state[key] = value
+    }
+    return state, s.txCount
+}
+
+// Restore replaces the committed state by a snapshot, the TxIDs continue after txCount transactions so the
+// keys of the new tasks do not overwrite the tasks of the snapshot
+func (s *Stub) Restore(state map[string][]byte, txCount int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.state = make(map[string][]byte, len(state))
+    for key, value := range state {
+        s.state[key] = value
+    }
+    s.txCount = txCount
+}
+
+// GetTxID returns the ID of the running transaction
+func (s *Stub) GetTxID() string {
+    return s.txID
+}
+
+// GetTxTimestamp returns the time given to Begin
+func (s *Stub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+    return timestamppb.New(s.txTime), nil
+}
+
+// GetState reads the committed value of a key, nil if the key does not exist
+func (s *Stub) GetState(key string) ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.state[key], nil
+}
+
+// PutState writes a key at the commit of the transaction
+func (s *Stub) PutState(key string, value []byte) error {
+    if key == "" {
+        return fmt.Errorf("Empty key")
+    }
+    if value == nil {
+        value = []byte{}
+    }
+    s.writes[key] = value
+    return nil
+}
+
+// DelState deletes a key at the commit of the transaction
+func (s *Stub) DelState(key string) error {
+    s.writes[key] = nil
+    return nil
+}
+
+// SetEvent records the event of the transaction, returned by Commit
+func (s *Stub) SetEvent(name string, payload []byte) error {
+    if name == "" {
+        return fmt.Errorf("Empty event name")
+    }
+    s.events[name] = payload
+    return nil
+}
+
+// GetStateByRange returns the committed keys between startKey (included) and endKey (excluded) in order
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    var results []*queryresult.KV
+    for key, value := range s.state {
+        if key >= startKey && (endKey == "" || key < endKey) {
+            results = append(results, &queryresult.KV{Key: key, Value: value})
+        }
+    }
+    sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+    return &iterator{results: results}, nil
+}
+
+// iterator goes through the results of a range query
+type iterator struct {
+    results []*queryresult.KV
+    next    int
+}
+
+func (it *iterator) HasNext() bool {
+    return it.next < len(it.results)
+}
+
+func (it *iterator) Next() (*queryresult.KV, error) {
+    if !it.HasNext() {
+        return nil, fmt.Errorf("No more results")
+    }
+    it.next++
+    return it.results[it.next-1], nil
+}
+
+func (it *iterator) Close() error {
+    return nil
+}
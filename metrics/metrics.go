@@ -0,0 +1,175 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Prometheus metrics of the simulation and of the client tools
+//
+// version : 1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - Counters of the tasks submitted, succeeded and failed by strategy, type and error
+//      code, retries, histograms of the submit latency and of the wait for a client
+//      - Gauges of the fleet read with GetNetworkStats: UAV battery average, available UAVs
+//      and task share of the UAV and EC
+//      - Served on /metrics for a local Prometheus / Grafana, all the methods do nothing on a
+//      nil *Metrics so the commands work the same without the endpoint
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package metrics
+
+import (
+    "fmt"
+    "net"
+    "net/http"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+
+    "github.com/RezanOscar/COBRA/scheduler"
+)
+
+// Metrics are the collectors of a run, in their own registry
+type Metrics struct {
+    registry      *prometheus.Registry
+    submitted     *prometheus.CounterVec
+    succeeded     *prometheus.CounterVec
+    failed        *prometheus.CounterVec
+    retries       *prometheus.CounterVec
+    latency       *prometheus.HistogramVec
+    wait          prometheus.Histogram
+    inFlight      prometheus.Gauge
+    registered    *prometheus.CounterVec
+    uavBattery    prometheus.Gauge
+    availableUAVs prometheus.Gauge
+    taskShare     *prometheus.GaugeVec
+}
+
+// New creates the metrics
+func New() *Metrics {
+    m := &Metrics{
+        registry: prometheus.NewRegistry(),
+        submitted: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "cobra_tasks_submitted_total",
+            Help: "Tasks sent to the offload function, once whatever their retries",
+        }, []string{"strategy", "type"}),
+        succeeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "cobra_tasks_succeeded_total",
+            Help: "Tasks assigned to a device, by the type of the device",
+        }, []string{"strategy", "type", "device_type"}),
+        failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "cobra_tasks_failed_total",
+            Help: "Tasks failed after their retries, by error code of the last attempt",
+        }, []string{"strategy", "type", "code"}),
+        retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "cobra_task_retries_total",
+            Help: "Attempts sent again after a failure",
+        }, []string{"strategy", "type"}),
+        latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+            Name:    "cobra_submit_latency_seconds",
+            Help:    "Time between the submission and the commit of an attempt",
+            Buckets: prometheus.ExponentialBuckets(0.01, 2, 12), // 10 ms to 20 s
+        }, []string{"strategy", "type"}),
+        wait: prometheus.NewHistogram(prometheus.HistogramOpts{
+            Name:    "cobra_task_wait_seconds",
+            Help:    "Time a task waited for a free client in the open loop",
+            Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+        }),
+        inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+            Name: "cobra_tasks_in_flight",
+            Help: "Tasks sent and not finished",
+        }),
+        registered: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "cobra_devices_registered_total",
+            Help: "Devices registered by the client",
+        }, []string{"device_type"}),
+        uavBattery: prometheus.NewGauge(prometheus.GaugeOpts{
+            Name: "cobra_uav_battery_average_percent",
+            Help: "Average battery of the UAVs at the last stats of the network",
+        }),
+        availableUAVs: prometheus.NewGauge(prometheus.GaugeOpts{
+            Name: "cobra_uavs_available",
+            Help: "Available UAVs at the last stats of the network",
+        }),
+        taskShare: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+            Name: "cobra_task_share_percent",
+            Help: "Share of the tasks of the UAVs and ECs assigned to each type at the last stats of the network",
+        }, []string{"device_type"}),
+    }
+    m.registry.MustRegister(m.submitted, m.succeeded, m.failed, m.retries, m.latency, m.wait, m.inFlight,
+        m.registered, m.uavBattery, m.availableUAVs, m.taskShare)
+    return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+    return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves the metrics on http://addr/metrics in the background, it returns the address listened (with the
+// port chosen for :0) and the function stopping the server
+func (m *Metrics) Serve(addr string) (string, func() error, error) {
+    listener, err := net.Listen("tcp", addr)
+    if err != nil {
+        return "", nil, fmt.Errorf("Failed to listen for the metrics on %s: %w", addr, err)
+    }
+    mux := http.NewServeMux()
+    mux.Handle("/metrics", m.Handler())
+    server := &http.Server{Handler: mux}
+    go server.Serve(listener)
+    return listener.Addr().String(), server.Close, nil
+}
+
+// TaskIssued counts a task sent to the offload function
+func (m *Metrics) TaskIssued(strategy string, taskType string) {
+    if m == nil {
+        return
+    }
+    m.submitted.WithLabelValues(strategy, taskType).Inc()
+    m.inFlight.Inc()
+}
+
+// Attempt records the latency of an attempt of a task
+func (m *Metrics) Attempt(strategy string, taskType string, latency time.Duration) {
+    if m == nil {
+        return
+    }
+    m.latency.WithLabelValues(strategy, taskType).Observe(latency.Seconds())
+}
+
+// TaskDone counts a finished task with its retries and its wait for a client, the device type of a success or the
+// code of a failure
+func (m *Metrics) TaskDone(strategy string, taskType string, success bool, deviceType string, code string, attempts int, wait time.Duration) {
+    if m == nil {
+        return
+    }
+    if success {
+        m.succeeded.WithLabelValues(strategy, taskType, deviceType).Inc()
+    } else {
+        m.failed.WithLabelValues(strategy, taskType, code).Inc()
+    }
+    if attempts > 1 {
+        m.retries.WithLabelValues(strategy, taskType).Add(float64(attempts - 1))
+    }
+    m.wait.Observe(wait.Seconds())
+    m.inFlight.Dec()
+}
+
+// DeviceRegistered counts a registered device
+func (m *Metrics) DeviceRegistered(deviceType string) {
+    if m == nil {
+        return
+    }
+    m.registered.WithLabelValues(deviceType).Inc()
+}
+
+// NetworkStats sets the gauges of the fleet, the battery of 50 is 100%
+func (m *Metrics) NetworkStats(stats scheduler.NetworkStats) {
+    if m == nil {
+        return
+    }
+    m.uavBattery.Set(stats.AvgUAVBattery * 2)
+    m.availableUAVs.Set(float64(stats.AvailableUAVs))
+    m.taskShare.WithLabelValues("UAV").Set(stats.TaskShareUAV)
+    m.taskShare.WithLabelValues("EC").Set(stats.TaskShareEC)
+}
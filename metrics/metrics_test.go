@@ -0,0 +1,87 @@
+package metrics
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/RezanOscar/COBRA/scheduler"
+)
+
+// scrape returns the metrics served by the handler
+func scrape(t *testing.T, handler http.Handler) string {
+    t.Helper()
+    server := httptest.NewServer(handler)
+    defer server.Close()
+    resp, err := http.Get(server.URL)
+    if err != nil {
+        t.Fatalf("Failed to scrape the metrics: %v", err)
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        t.Fatalf("Failed to read the metrics: %v", err)
+    }
+    return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+    m := New()
+    m.DeviceRegistered("UAV")
+    m.TaskIssued("TaskOffloadCobra", "MC")
+    m.Attempt("TaskOffloadCobra", "MC", 50*time.Millisecond)
+    m.Attempt("TaskOffloadCobra", "MC", 80*time.Millisecond)
+    m.TaskDone("TaskOffloadCobra", "MC", true, "UAV", "", 2, time.Second)
+    m.TaskIssued("TaskOffloadCobra", "IC")
+    m.Attempt("TaskOffloadCobra", "IC", 20*time.Millisecond)
+    m.TaskDone("TaskOffloadCobra", "IC", false, "", "NO_CANDIDATE", 1, 0)
+    m.NetworkStats(scheduler.NetworkStats{AvgUAVBattery: 40, AvailableUAVs: 3, TaskShareUAV: 75, TaskShareEC: 25})
+
+    body := scrape(t, m.Handler())
+    for _, want := range []string{
+        `cobra_tasks_submitted_total{strategy="TaskOffloadCobra",type="MC"} 1`,
+        `cobra_tasks_succeeded_total{device_type="UAV",strategy="TaskOffloadCobra",type="MC"} 1`,
+        `cobra_tasks_failed_total{code="NO_CANDIDATE",strategy="TaskOffloadCobra",type="IC"} 1`,
+        `cobra_task_retries_total{strategy="TaskOffloadCobra",type="MC"} 1`,
+        `cobra_submit_latency_seconds_count{strategy="TaskOffloadCobra",type="MC"} 2`,
+        `cobra_task_wait_seconds_count 2`,
+        `cobra_tasks_in_flight 0`,
+        `cobra_devices_registered_total{device_type="UAV"} 1`,
+        `cobra_uav_battery_average_percent 80`,
+        `cobra_uavs_available 3`,
+        `cobra_task_share_percent{device_type="EC"} 25`,
+    } {
+        if !strings.Contains(body, want) {
+            t.Errorf("Missing %s in the metrics:\n%s", want, body)
+        }
+    }
+}
+
+func TestServe(t *testing.T) {
+    m := New()
+    m.TaskIssued("TaskOffloadCobra", "MC")
+    addr, stop, err := m.Serve("127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Serve failed: %v", err)
+    }
+    defer stop()
+
+    resp, err := http.Get("http://" + addr + "/metrics")
+    if err != nil {
+        t.Fatalf("Failed to scrape http://%s/metrics: %v", addr, err)
+    }
+    body, _ := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if !strings.Contains(string(body), "cobra_tasks_in_flight 1") {
+        t.Errorf("Missing the task in flight in:\n%s", body)
+    }
+
+    // The methods do nothing without metrics
+    var none *Metrics
+    none.TaskIssued("TaskOffloadCobra", "MC")
+    none.TaskDone("TaskOffloadCobra", "MC", true, "UAV", "", 1, 0)
+    none.NetworkStats(scheduler.NetworkStats{})
+}
@@ -0,0 +1,240 @@
+package report
+
+import (
+    "math"
+    "strconv"
+    "strings"
+)
+
+// Size of the charts and margins of the plot area, the legend is on the right
+const (
+    chartWidth   = 800
+    chartHeight  = 450
+    marginLeft   = 80
+    marginRight  = 170 // 30 without legend, for the bar charts
+    marginTop    = 45
+    marginBottom = 60
+)
+
+// Series is a line of a chart
+type Series struct {
+    Label  string
+    Color  string // Label giving the color, the series of a run have the color of the run; Label when empty
+    Dashed bool
+    X      []float64
+    Y      []float64
+}
+
+// Bar is a bar of a chart with its interval, Low and High are Value without interval
+type Bar struct {
+    Label string
+    Value float64
+    Low   float64
+    High  float64
+}
+
+// Chart is a line chart, or a bar chart when it has bars
+type Chart struct {
+    Name   string // File name without extension
+    Title  string
+    XLabel string
+    YLabel string
+    YMin   float64 // Range of the Y axis when YMax > YMin, else from 0 to the highest value
+    YMax   float64
+    Lines  []Series
+    Bars   []Bar
+}
+
+// palette are the colors of the offload models, the same as the graphs of the paper
+var palette = map[string]string{
+    "Cobra":          "#4169e1",
+    "ECP":            "#008000",
+    "EnergyAware":    "#ff0000",
+    "Random":         "#ffa500",
+    "RoundRobin":     "#9400d3",
+    "FirstAvailable": "#8b4513",
+}
+
+// otherColors are the colors of the other runs, in their order in the chart
+var otherColors = []string{"#17becf", "#e377c2", "#7f7f7f", "#bcbd22", "#1f77b4", "#2ca02c"}
+
+// canvas is the surface a chart is drawn on, the coordinates are in pixels from the top left corner
+type canvas interface {
+    line(x1, y1, x2, y2 float64, color string, width float64, dashed bool)
+    rect(x, y, w, h float64, color string)
+    // text draws s with its baseline at y, anchored at x by its start, middle or end, vertical texts go up
+    text(x, y float64, s string, size float64, anchor string, vertical bool)
+}
+
+// colors gives the color of each label of the chart
+func (c Chart) colors() map[string]string {
+    colors := map[string]string{}
+    other := 0
+    add := func(label string) {
+        if _, ok := colors[label]; ok {
+            return
+        }
+        if color, ok := palette[strings.TrimPrefix(label, "des_")]; ok {
+            colors[label] = color
+            return
+        }
+        colors[label] = otherColors[other%len(otherColors)]
+        other++
+    }
+    for _, series := range c.Lines {
+        add(series.colorLabel())
+    }
+    for _, bar := range c.Bars {
+        add(bar.Label)
+    }
+    return colors
+}
+
+func (s Series) colorLabel() string {
+    if s.Color != "" {
+        return s.Color
+    }
+    return s.Label
+}
+
+// plotWidth is the width of the plot area, the bar charts have no legend
+func (c Chart) plotWidth() float64 {
+    if len(c.Bars) > 0 {
+        return chartWidth - marginLeft - 30
+    }
+    return chartWidth - marginLeft - marginRight
+}
+
+// draw lays the chart out on the canvas
+func (c Chart) draw(cv canvas) {
+    colors := c.colors()
+    plotWidth := c.plotWidth()
+    plotHeight := float64(chartHeight - marginTop - marginBottom)
+    left, top := float64(marginLeft), float64(marginTop)
+    bottom := top + plotHeight
+
+    cv.rect(0, 0, chartWidth, chartHeight, "#ffffff")
+    cv.text(chartWidth/2, 25, c.Title, 16, "middle", false)
+
+    // Range of the Y axis
+    yLow, yHigh := 0.0, 0.0
+    for _, series := range c.Lines {
+        for _, y := range series.Y {
+            if !math.IsNaN(y) {
+                yLow, yHigh = math.Min(yLow, y), math.Max(yHigh, y)
+            }
+        }
+    }
+    for _, bar := range c.Bars {
+        yLow, yHigh = math.Min(yLow, bar.Low), math.Max(yHigh, math.Max(bar.Value, bar.High))
+    }
+    yLow, yHigh, yStep := niceTicks(yLow, yHigh)
+    if c.YMax > c.YMin {
+        yLow, yHigh = c.YMin, c.YMax
+        _, _, yStep = niceTicks(yLow, yHigh)
+    }
+    yPos := func(y float64) float64 {
+        y = math.Max(yLow, math.Min(yHigh, y))
+        return bottom - (y-yLow)/(yHigh-yLow)*plotHeight
+    }
+    for i := 0; yLow+float64(i)*yStep <= yHigh+yStep/1e6; i++ {
+        y := yLow + float64(i)*yStep
+        cv.line(left, yPos(y), left+plotWidth, yPos(y), "#dddddd", 1, false)
+        cv.text(left-8, yPos(y)+4, formatTick(y, yStep), 11, "end", false)
+    }
+
+    if len(c.Bars) > 0 {
+        c.drawBars(cv, colors, yPos)
+    } else {
+        c.drawLines(cv, colors, yPos)
+    }
+
+    cv.line(left, top, left, bottom, "#000000", 1, false)
+    cv.line(left, bottom, left+plotWidth, bottom, "#000000", 1, false)
+    cv.text(20, top+plotHeight/2, c.YLabel, 12, "middle", true)
+    cv.text(left+plotWidth/2, chartHeight-15, c.XLabel, 12, "middle", false)
+}
+
+// drawLines draws the series with the X axis and the legend
+func (c Chart) drawLines(cv canvas, colors map[string]string, yPos func(float64) float64) {
+    plotWidth := c.plotWidth()
+    left, bottom := float64(marginLeft), float64(chartHeight-marginBottom)
+
+    xLow, xHigh := math.Inf(1), math.Inf(-1)
+    for _, series := range c.Lines {
+        for _, x := range series.X {
+            xLow, xHigh = math.Min(xLow, x), math.Max(xHigh, x)
+        }
+    }
+    if math.IsInf(xLow, 0) {
+        xLow, xHigh = 0, 1
+    }
+    xLow, xHigh, xStep := niceTicks(xLow, xHigh)
+    xPos := func(x float64) float64 {
+        return left + (x-xLow)/(xHigh-xLow)*plotWidth
+    }
+    for i := 0; xLow+float64(i)*xStep <= xHigh+xStep/1e6; i++ {
+        x := xLow + float64(i)*xStep
+        cv.line(xPos(x), float64(marginTop), xPos(x), bottom, "#eeeeee", 1, false)
+        cv.text(xPos(x), bottom+18, formatTick(x, xStep), 11, "middle", false)
+    }
+
+    for i, series := range c.Lines {
+        color := colors[series.colorLabel()]
+        for j := 1; j < len(series.X) && j < len(series.Y); j++ {
+            if math.IsNaN(series.Y[j-1]) || math.IsNaN(series.Y[j]) {
+                continue
+            }
+            cv.line(xPos(series.X[j-1]), yPos(series.Y[j-1]), xPos(series.X[j]), yPos(series.Y[j]), color, 2, series.Dashed)
+        }
+        legendY := float64(marginTop + 10 + 18*i)
+        legendX := float64(chartWidth - marginRight + 15)
+        cv.line(legendX, legendY-4, legendX+25, legendY-4, color, 2, series.Dashed)
+        cv.text(legendX+32, legendY, series.Label, 11, "start", false)
+    }
+}
+
+// drawBars draws a bar by label with its interval
+func (c Chart) drawBars(cv canvas, colors map[string]string, yPos func(float64) float64) {
+    plotWidth := c.plotWidth()
+    slot := plotWidth / float64(len(c.Bars))
+    width := slot * 0.6
+    base := yPos(0)
+    for i, bar := range c.Bars {
+        center := float64(marginLeft) + slot*(float64(i)+0.5)
+        top := yPos(bar.Value)
+        cv.rect(center-width/2, math.Min(top, base), width, math.Abs(base-top), colors[bar.Label])
+        if bar.High > bar.Low {
+            cv.line(center, yPos(bar.Low), center, yPos(bar.High), "#000000", 1.5, false)
+            cv.line(center-8, yPos(bar.Low), center+8, yPos(bar.Low), "#000000", 1.5, false)
+            cv.line(center-8, yPos(bar.High), center+8, yPos(bar.High), "#000000", 1.5, false)
+        }
+        cv.text(center, float64(chartHeight-marginBottom)+18, bar.Label, 11, "middle", false)
+    }
+}
+
+// niceTicks extends a range to steps of 1, 2 or 5 times a power of 10, with about 6 steps
+func niceTicks(low float64, high float64) (float64, float64, float64) {
+    if high <= low {
+        high = low + 1
+    }
+    raw := (high - low) / 6
+    magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+    step := 10 * magnitude
+    for _, m := range []float64{1, 2, 5} {
+        if raw <= m*magnitude {
+            step = m * magnitude
+            break
+        }
+    }
+    return math.Floor(low/step) * step, math.Ceil(high/step) * step, step
+}
+
+// formatTick writes a tick with the decimals of the step
+func formatTick(value float64, step float64) string {
+    decimals := 0
+    if step < 1 {
+        decimals = int(math.Ceil(-math.Log10(step) - 1e-9))
+    }
+    return strconv.FormatFloat(value, 'f', decimals, 64)
+}
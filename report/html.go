@@ -0,0 +1,91 @@
+package report
+
+import (
+    "fmt"
+    "html/template"
+    "io"
+
+    "github.com/RezanOscar/COBRA/stats"
+)
+
+// htmlTemplate is the page of the report, the charts are inline SVG so the file is self-contained
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>COBRA simulation report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #f0f0f0; }
+figure { margin: 0 0 2em 0; }
+</style>
+</head>
+<body>
+<h1>COBRA simulation report</h1>
+<h2>Summary</h2>
+<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+<p>The results are the last line of graphe_result_&lt;run&gt;.csv, the latencies (from the first submission to the commit of the successful tasks) are read in the task log tasks_&lt;run&gt;, with the {{.Level}}% confidence interval of their mean.</p>
+<h2>Charts</h2>
+{{range .Charts}}<figure>
+{{.}}
+</figure>
+{{end}}</body>
+</html>
+`))
+
+// summaryHeader are the columns of the summary table
+var summaryHeader = []string{
+    "Run", "Tasks", "UAV battery (%)", "Available UAVs", "UAV share (%)", "EC share (%)", "Time delay (s)",
+    "Logged tasks", "Failed", "Mean latency (s)", "CI (s)", "P50 (s)", "P95 (s)", "P99 (s)",
+}
+
+// summaryRow is the line of a run in the summary, - for the values it does not have
+func summaryRow(r Run, level float64) []string {
+    row := []string{r.Label()}
+    for _, column := range []string{ColumnTasks, ColumnBattery, ColumnAvailable, ColumnShareUAV, ColumnShareEC, ColumnTimeDelay} {
+        if len(r.Columns[column]) == 0 {
+            row = append(row, "-")
+            continue
+        }
+        row = append(row, fmt.Sprintf("%.2f", r.Last(column)))
+    }
+    if r.Logged == 0 {
+        return append(row, "-", "-", "-", "-", "-", "-", "-")
+    }
+    row = append(row, fmt.Sprintf("%d", r.Logged), fmt.Sprintf("%d", r.Failed))
+    if len(r.Latencies) == 0 {
+        return append(row, "-", "-", "-", "-", "-")
+    }
+    s := stats.Summarize(r.Latencies, level, 0)
+    return append(row,
+        fmt.Sprintf("%.3f", s.Mean),
+        fmt.Sprintf("[%.3f, %.3f]", s.CI.Low, s.CI.High),
+        fmt.Sprintf("%.3f", s.P50),
+        fmt.Sprintf("%.3f", s.P95),
+        fmt.Sprintf("%.3f", s.P99),
+    )
+}
+
+// WriteHTML writes the summary of the runs and the charts in one HTML page
+func WriteHTML(w io.Writer, runs []Run, charts []Chart, level float64) error {
+    data := struct {
+        Header []string
+        Rows   [][]string
+        Level  float64
+        Charts []template.HTML
+    }{Header: summaryHeader, Level: level * 100}
+    for _, r := range runs {
+        data.Rows = append(data.Rows, summaryRow(r, level))
+    }
+    for _, chart := range charts {
+        // The SVG is built by the package with the texts escaped
+        data.Charts = append(data.Charts, template.HTML(chart.SVG()))
+    }
+    return htmlTemplate.Execute(w, data)
+}
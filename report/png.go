@@ -0,0 +1,167 @@
+package report
+
+import (
+    "image"
+    "image/color"
+    "image/png"
+    "io"
+    "math"
+    "strconv"
+    "strings"
+)
+
+// pngCanvas draws a chart on an image, the texts are written in capitals with the 5x7 font
+type pngCanvas struct {
+    img *image.RGBA
+}
+
+func (p *pngCanvas) line(x1, y1, x2, y2 float64, color string, width float64, dashed bool) {
+    c := parseColor(color)
+    length := math.Hypot(x2-x1, y2-y1)
+    // Square brush of the width of the line
+    size := int(math.Max(1, math.Round(width)))
+    start := -(size - 1) / 2
+    for d := 0.0; d <= length; d += 0.5 {
+        // Dashes of 6 pixels every 10 pixels, like the SVG
+        if dashed && math.Mod(d, 10) >= 6 {
+            continue
+        }
+        t := 0.0
+        if length > 0 {
+            t = d / length
+        }
+        x := int(math.Round(x1 + t*(x2-x1)))
+        y := int(math.Round(y1 + t*(y2-y1)))
+        for dx := start; dx < start+size; dx++ {
+            for dy := start; dy < start+size; dy++ {
+                p.img.Set(x+dx, y+dy, c)
+            }
+        }
+    }
+}
+
+func (p *pngCanvas) rect(x, y, w, h float64, color string) {
+    c := parseColor(color)
+    for i := int(math.Round(x)); i < int(math.Round(x+w)); i++ {
+        for j := int(math.Round(y)); j < int(math.Round(y+h)); j++ {
+            p.img.Set(i, j, c)
+        }
+    }
+}
+
+func (p *pngCanvas) text(x, y float64, text string, size float64, anchor string, vertical bool) {
+    scale := 1
+    if size >= 12 {
+        scale = 2
+    }
+    text = strings.ToUpper(text)
+    advance := 6 * scale
+    length := float64(len([]rune(text))*advance - scale)
+    offset := 0.0
+    switch anchor {
+    case "middle":
+        offset = -length / 2
+    case "end":
+        offset = -length
+    }
+
+    black := color.RGBA{0, 0, 0, 255}
+    for i, r := range []rune(text) {
+        glyph, ok := font[r]
+        if !ok {
+            continue
+        }
+        for row := 0; row < 7; row++ {
+            for col := 0; col < 5; col++ {
+                if glyph[row][col] != '#' {
+                    continue
+                }
+                // Position of the pixel along the text and above the baseline
+                along := int(offset) + i*advance + col*scale
+                above := (7 - row) * scale
+                for sx := 0; sx < scale; sx++ {
+                    for sy := 0; sy < scale; sy++ {
+                        if vertical {
+                            p.img.Set(int(x)-above+sy, int(y)-along-sx, black)
+                        } else {
+                            p.img.Set(int(x)+along+sx, int(y)-above+sy, black)
+                        }
+                    }
+                }
+            }
+        }
+    }
+}
+
+// parseColor reads a color #rrggbb, black if it is not one
+func parseColor(hex string) color.RGBA {
+    value, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+    if err != nil || len(hex) != 7 {
+        return color.RGBA{0, 0, 0, 255}
+    }
+    return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 255}
+}
+
+// PNG is the chart as an image
+func (c Chart) PNG() *image.RGBA {
+    p := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))}
+    c.draw(p)
+    return p.img
+}
+
+// WritePNG writes the chart in PNG
+func (c Chart) WritePNG(w io.Writer) error {
+    return png.Encode(w, c.PNG())
+}
+
+// font is a 5x7 bitmap font of the capitals, the digits and the punctuation of the charts
+var font = map[rune][7]string{
+    ' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+    'A': {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+    'B': {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+    'C': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+    'D': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+    'E': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+    'F': {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+    'G': {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+    'H': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+    'I': {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+    'J': {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+    'K': {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+    'L': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+    'M': {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+    'N': {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+    'O': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+    'P': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+    'Q': {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+    'R': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+    'S': {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+    'T': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+    'U': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+    'V': {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+    'W': {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+    'X': {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+    'Y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+    'Z': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+    '0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+    '1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+    '2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+    '3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+    '4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+    '5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+    '6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+    '7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+    '8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+    '9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+    '.': {".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+    ',': {".....", ".....", ".....", ".....", ".##..", "..#..", ".#..."},
+    '-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+    '%': {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+    '(': {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+    ')': {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+    '/': {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+    ':': {".....", ".##..", ".##..", ".....", ".##..", ".##..", "....."},
+    '_': {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+    '+': {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+    '=': {".....", ".....", "#####", ".....", "#####", ".....", "....."},
+}
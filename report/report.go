@@ -0,0 +1,360 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Charts and HTML report of the results of the simulations
+//
+// version : 1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - Reads the graphe_result_<function>.csv of the simulations of a directory and the task
+//      logs tasks_<function>.jsonl or .csv next to them, instead of the numbers copied by hand
+//      in the previous python script generate_graphe.py
+//      - Charts: UAV battery and available UAVs over the tasks, share of the tasks on the UAV
+//      and the EC, CDF of the latency of the tasks and comparison of the strategies (time
+//      delay and mean latency with its confidence interval)
+//      - chart.go : layout of the charts, drawn on a canvas
+//      - svg.go : SVG canvas
+//      - png.go : PNG canvas with a 5x7 bitmap font, without dependency
+//      - html.go : self-contained HTML summary with the SVG charts inline
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package report
+
+import (
+    "bufio"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "math"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/RezanOscar/COBRA/stats"
+)
+
+// Columns of graphe_result_<function>.csv drawn by the report
+const (
+    ColumnTasks     = "Total Tasks"
+    ColumnBattery   = "UAV Battery Avg"
+    ColumnAvailable = "UAV Available"
+    ColumnTimeDelay = "Time Delay"
+    ColumnShareUAV  = "TotalTaskUAV (%)"
+    ColumnShareEC   = "TotalTaskEC (%)"
+)
+
+// Formats of the charts
+const (
+    FormatSVG = "svg"
+    FormatPNG = "png"
+    FormatAll = "all"
+)
+
+// Run is a simulation of the directory, its name is the one of its files: graphe_result_<name>.csv and
+// tasks_<name>.jsonl (TaskOffloadCobra, des_TaskOffloadCobra ...)
+type Run struct {
+    Name      string
+    Columns   map[string][]float64 // Columns of graphe_result by name, nil without results
+    Logged    int                  // Tasks of the task log
+    Failed    int                  // Failed tasks of the task log
+    Latencies []float64            // Latencies (s) from the first submission to the commit of the successful tasks of the task log
+}
+
+// Label is the name of the run without the prefix of the offload functions: Cobra, des_Cobra ...
+func (r Run) Label() string {
+    label := strings.Replace(r.Name, "TaskOffloading", "", 1)
+    return strings.Replace(label, "TaskOffload", "", 1)
+}
+
+// Last is the last value of a column of the results, 0 if the column is missing or empty
+func (r Run) Last(column string) float64 {
+    values := r.Columns[column]
+    for i := len(values) - 1; i >= 0; i-- {
+        if !math.IsNaN(values[i]) {
+            return values[i]
+        }
+    }
+    return 0
+}
+
+// taskRecord are the fields of the task log used by the report
+type taskRecord struct {
+    Submitted time.Time `json:"submitted"`
+    Committed time.Time `json:"committed"`
+    Success   bool      `json:"success"`
+}
+
+// Load reads the results and the task logs of the simulations of the directory, sorted by name
+func Load(dir string) ([]Run, error) {
+    runs := map[string]*Run{}
+    run := func(name string) *Run {
+        if runs[name] == nil {
+            runs[name] = &Run{Name: name}
+        }
+        return runs[name]
+    }
+
+    results, err := filepath.Glob(filepath.Join(dir, "graphe_result_*.csv"))
+    if err != nil {
+        return nil, err
+    }
+    for _, path := range results {
+        columns, err := ReadResults(path)
+        if err != nil {
+            return nil, err
+        }
+        name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "graphe_result_"), ".csv")
+        run(name).Columns = columns
+    }
+
+    for _, ext := range []string{".jsonl", ".csv"} {
+        logs, err := filepath.Glob(filepath.Join(dir, "tasks_*"+ext))
+        if err != nil {
+            return nil, err
+        }
+        for _, path := range logs {
+            records, err := readTaskLog(path)
+            if err != nil {
+                return nil, err
+            }
+            r := run(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "tasks_"), ext))
+            for _, record := range records {
+                r.Logged++
+                if !record.Success {
+                    r.Failed++
+                    continue
+                }
+                r.Latencies = append(r.Latencies, record.Committed.Sub(record.Submitted).Seconds())
+            }
+        }
+    }
+
+    if len(runs) == 0 {
+        return nil, fmt.Errorf("No graphe_result_*.csv nor tasks_* log in %s", dir)
+    }
+    sorted := make([]Run, 0, len(runs))
+    for _, r := range runs {
+        sorted = append(sorted, *r)
+    }
+    sort.Slice(sorted, func(i, j int) bool {
+        return sorted[i].Name < sorted[j].Name
+    })
+    return sorted, nil
+}
+
+// ReadResults reads the columns of a graphe_result CSV by name, the files of the previous versions have fewer columns
+// and empty values, read as NaN and not drawn
+func ReadResults(path string) (map[string][]float64, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open results: %w", err)
+    }
+    defer file.Close()
+
+    rows, err := csv.NewReader(file).ReadAll()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read %s: %w", path, err)
+    }
+    if len(rows) == 0 {
+        return nil, fmt.Errorf("failed to read %s: no header", path)
+    }
+    columns := map[string][]float64{}
+    for line, row := range rows[1:] {
+        for i, name := range rows[0] {
+            if i >= len(row) {
+                break
+            }
+            name = strings.TrimSpace(name)
+            value := math.NaN()
+            if text := strings.TrimSpace(row[i]); text != "" {
+                value, err = strconv.ParseFloat(text, 64)
+                if err != nil {
+                    return nil, fmt.Errorf("failed to parse %s line %d column %s: %w", path, line+2, name, err)
+                }
+            }
+            columns[name] = append(columns[name], value)
+        }
+    }
+    return columns, nil
+}
+
+// readTaskLog reads a task log in JSONL or CSV, by its extension
+func readTaskLog(path string) ([]taskRecord, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open task log: %w", err)
+    }
+    defer file.Close()
+
+    var records []taskRecord
+    if filepath.Ext(path) == ".jsonl" {
+        scanner := bufio.NewScanner(file)
+        scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+        for line := 1; scanner.Scan(); line++ {
+            if strings.TrimSpace(scanner.Text()) == "" {
+                continue
+            }
+            var record taskRecord
+            if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+                return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+            }
+            records = append(records, record)
+        }
+        return records, scanner.Err()
+    }
+
+    rows, err := csv.NewReader(file).ReadAll()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read %s: %w", path, err)
+    }
+    if len(rows) == 0 {
+        return nil, nil
+    }
+    index := map[string]int{}
+    for i, name := range rows[0] {
+        index[name] = i
+    }
+    for _, name := range []string{"submitted", "committed", "success"} {
+        if _, ok := index[name]; !ok {
+            return nil, fmt.Errorf("failed to read %s: no column %s", path, name)
+        }
+    }
+    for line, row := range rows[1:] {
+        var record taskRecord
+        record.Submitted, err = time.Parse(time.RFC3339Nano, row[index["submitted"]])
+        if err == nil {
+            record.Committed, err = time.Parse(time.RFC3339Nano, row[index["committed"]])
+        }
+        if err == nil {
+            record.Success, err = strconv.ParseBool(row[index["success"]])
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line+2, err)
+        }
+        records = append(records, record)
+    }
+    return records, nil
+}
+
+// Charts are the charts of the runs, the comparison of the latencies has the confidence intervals at level
+func Charts(runs []Run, level float64) []Chart {
+    battery := Chart{Name: "battery", Title: "Average UAV battery", XLabel: "Total tasks offloaded", YLabel: "Average UAV battery (%)", YMin: 0, YMax: 100}
+    available := Chart{Name: "uav_available", Title: "Available UAVs", XLabel: "Total tasks offloaded", YLabel: "Number of available UAV"}
+    share := Chart{Name: "share", Title: "Share of the tasks on the UAV (solid) and the EC (dashed)", XLabel: "Total tasks offloaded", YLabel: "Share of the tasks (%)", YMin: 0, YMax: 100}
+    cdf := Chart{Name: "latency_cdf", Title: "CDF of the latency of the tasks", XLabel: "Latency (s)", YLabel: "Tasks (%)", YMin: 0, YMax: 100}
+    delay := Chart{Name: "compare_delay", Title: "Time delay at the end of the simulation", YLabel: "Time delay (s)"}
+    latency := Chart{Name: "compare_latency", Title: fmt.Sprintf("Mean latency of the tasks with the %g%% confidence interval", level*100), YLabel: "Latency (s)"}
+
+    for _, r := range runs {
+        label := r.Label()
+        if tasks := r.Columns[ColumnTasks]; len(tasks) > 0 {
+            battery.Lines = appendSeries(battery.Lines, Series{Label: label, X: tasks, Y: r.Columns[ColumnBattery]})
+            available.Lines = appendSeries(available.Lines, Series{Label: label, X: tasks, Y: r.Columns[ColumnAvailable]})
+            share.Lines = appendSeries(share.Lines, Series{Label: label + " UAV", Color: label, X: tasks, Y: r.Columns[ColumnShareUAV]})
+            share.Lines = appendSeries(share.Lines, Series{Label: label + " EC", Color: label, Dashed: true, X: tasks, Y: r.Columns[ColumnShareEC]})
+            if _, ok := r.Columns[ColumnTimeDelay]; ok {
+                value := r.Last(ColumnTimeDelay)
+                delay.Bars = append(delay.Bars, Bar{Label: label, Value: value, Low: value, High: value})
+            }
+        }
+        if len(r.Latencies) > 0 {
+            sorted := append([]float64(nil), r.Latencies...)
+            sort.Float64s(sorted)
+            cdf.Lines = append(cdf.Lines, cdfSeries(label, sorted))
+            ci := stats.CI(r.Latencies, level)
+            latency.Bars = append(latency.Bars, Bar{Label: label, Value: ci.Mean, Low: ci.Low, High: ci.High})
+        }
+    }
+
+    var charts []Chart
+    for _, chart := range []Chart{battery, available, share, cdf, delay, latency} {
+        if len(chart.Lines) > 0 || len(chart.Bars) > 0 {
+            charts = append(charts, chart)
+        }
+    }
+    return charts
+}
+
+// appendSeries adds a series that has values, the column may be missing in the results of the previous versions
+func appendSeries(lines []Series, series Series) []Series {
+    if len(series.Y) == 0 {
+        return lines
+    }
+    if len(series.Y) < len(series.X) {
+        series.X = series.X[:len(series.Y)]
+    }
+    return append(lines, series)
+}
+
+// cdfSeries is the empirical CDF of sorted latencies in %, with at most 200 points
+func cdfSeries(label string, sorted []float64) Series {
+    series := Series{Label: label}
+    step := (len(sorted) + 199) / 200
+    for i := step - 1; i < len(sorted); i += step {
+        series.X = append(series.X, sorted[i])
+        series.Y = append(series.Y, float64(i+1)/float64(len(sorted))*100)
+    }
+    if last := len(sorted) - 1; series.X[len(series.X)-1] != sorted[last] {
+        series.X = append(series.X, sorted[last])
+        series.Y = append(series.Y, 100)
+    }
+    return series
+}
+
+// Generate draws the charts of the results of the directory in out, in SVG, PNG or both (all), and writes the HTML
+// summary report.html. It returns the files written
+func Generate(dir string, out string, format string, level float64) ([]string, error) {
+    if format != FormatSVG && format != FormatPNG && format != FormatAll {
+        return nil, fmt.Errorf("The format %s must be %s, %s or %s", format, FormatSVG, FormatPNG, FormatAll)
+    }
+    if level <= 0 || level >= 1 {
+        return nil, fmt.Errorf("The confidence level must be between 0 and 1, got %g", level)
+    }
+    runs, err := Load(dir)
+    if err != nil {
+        return nil, err
+    }
+    if err := os.MkdirAll(out, 0755); err != nil {
+        return nil, fmt.Errorf("failed to create the report directory: %w", err)
+    }
+
+    charts := Charts(runs, level)
+    var files []string
+    write := func(name string, render func(*os.File) error) error {
+        path := filepath.Join(out, name)
+        file, err := os.Create(path)
+        if err != nil {
+            return fmt.Errorf("failed to create %s: %w", path, err)
+        }
+        err = render(file)
+        closeErr := file.Close()
+        if err != nil {
+            return fmt.Errorf("failed to write %s: %w", path, err)
+        }
+        if closeErr != nil {
+            return fmt.Errorf("failed to write %s: %w", path, closeErr)
+        }
+        files = append(files, path)
+        return nil
+    }
+
+    for _, chart := range charts {
+        chart := chart
+        if format == FormatSVG || format == FormatAll {
+            if err := write(chart.Name+".svg", func(file *os.File) error { return chart.WriteSVG(file) }); err != nil {
+                return files, err
+            }
+        }
+        if format == FormatPNG || format == FormatAll {
+            if err := write(chart.Name+".png", func(file *os.File) error { return chart.WritePNG(file) }); err != nil {
+                return files, err
+            }
+        }
+    }
+    err = write("report.html", func(file *os.File) error { return WriteHTML(file, runs, charts, level) })
+    return files, err
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+    "bytes"
+    "encoding/xml"
+    "image/png"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// writeFile writes a file of the test directory
+func writeFile(t *testing.T, dir string, name string, content string) {
+    t.Helper()
+    err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+    if err != nil {
+        t.Fatal(err)
+    }
+}
+
+// testDir has the results of a Cobra run with its JSONL task log and the task log in CSV of a des run
+func testDir(t *testing.T) string {
+    dir := t.TempDir()
+    writeFile(t, dir, "graphe_result_TaskOffloadCobra.csv", `Total Tasks,UAV Battery Avg,UAV Available,Time Delay,TotalTaskUAV (%),TotalTaskEC (%)
+0,100,27,0,0,0
+10,98.5,27,25.8,90,10
+20,96,26,51.2,85,15
+`)
+    writeFile(t, dir, "tasks_TaskOffloadCobra.jsonl", `{"taskID":"a","submitted":"2024-01-01T00:00:00Z","committed":"2024-01-01T00:00:02Z","success":true}
+{"taskID":"b","submitted":"2024-01-01T00:00:01Z","committed":"2024-01-01T00:00:05Z","success":true}
+{"taskID":"","submitted":"2024-01-01T00:00:02Z","committed":"2024-01-01T00:00:03Z","success":false}
+`)
+    writeFile(t, dir, "tasks_des_TaskOffloadECP.csv", `taskID,submitted,committed,success
+a,2024-01-01T00:00:00Z,2024-01-01T00:00:01.5Z,true
+`)
+    return dir
+}
+
+func TestLoad(t *testing.T) {
+    runs, err := Load(testDir(t))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(runs) != 2 || runs[0].Name != "TaskOffloadCobra" || runs[1].Label() != "des_ECP" {
+        t.Fatalf("Got runs %+v", runs)
+    }
+    cobra := runs[0]
+    if cobra.Last(ColumnAvailable) != 26 || cobra.Logged != 3 || cobra.Failed != 1 || len(cobra.Latencies) != 2 || cobra.Latencies[1] != 4 {
+        t.Errorf("Got run %+v", cobra)
+    }
+    if ecp := runs[1]; ecp.Columns != nil || len(ecp.Latencies) != 1 || ecp.Latencies[0] != 1.5 {
+        t.Errorf("Got run %+v", ecp)
+    }
+
+    if _, err := Load(t.TempDir()); err == nil {
+        t.Errorf("No error for a directory without results")
+    }
+}
+
+func TestCharts(t *testing.T) {
+    runs, _ := Load(testDir(t))
+    charts := Charts(runs, 0.95)
+    names := map[string]Chart{}
+    for _, chart := range charts {
+        names[chart.Name] = chart
+    }
+    for _, name := range []string{"battery", "uav_available", "share", "latency_cdf", "compare_delay", "compare_latency"} {
+        if _, ok := names[name]; !ok {
+            t.Errorf("Missing the chart %s", name)
+        }
+    }
+    if share := names["share"]; len(share.Lines) != 2 || !share.Lines[1].Dashed || share.Lines[1].Color != "Cobra" {
+        t.Errorf("Got the share lines %+v", share.Lines)
+    }
+    // The CDF ends at 100% at the highest latency, the ECP run has no results so no time delay
+    cdf := names["latency_cdf"].Lines[0]
+    if cdf.X[len(cdf.X)-1] != 4 || cdf.Y[len(cdf.Y)-1] != 100 {
+        t.Errorf("Got the CDF %+v", cdf)
+    }
+    if bars := names["compare_delay"].Bars; len(bars) != 1 || bars[0].Value != 51.2 {
+        t.Errorf("Got the delays %+v", bars)
+    }
+    if bars := names["compare_latency"].Bars; len(bars) != 2 || bars[0].Value != 3 || bars[0].High <= 3 {
+        t.Errorf("Got the latencies %+v", bars)
+    }
+}
+
+func TestRender(t *testing.T) {
+    runs, _ := Load(testDir(t))
+    for _, chart := range Charts(runs, 0.95) {
+        // The SVG is well formed XML
+        decoder := xml.NewDecoder(bytes.NewReader(chart.SVG()))
+        for {
+            _, err := decoder.Token()
+            if err == io.EOF {
+                break
+            }
+            if err != nil {
+                t.Fatalf("Invalid SVG of %s: %v", chart.Name, err)
+            }
+        }
+
+        var buf bytes.Buffer
+        if err := chart.WritePNG(&buf); err != nil {
+            t.Fatal(err)
+        }
+        img, err := png.Decode(&buf)
+        if err != nil || img.Bounds().Dx() != chartWidth || img.Bounds().Dy() != chartHeight {
+            t.Errorf("Invalid PNG of %s: %v", chart.Name, err)
+        }
+    }
+
+    low, high, step := niceTicks(0, 1003)
+    if low != 0 || high != 1200 || step != 200 {
+        t.Errorf("Got ticks %g %g %g", low, high, step)
+    }
+    if tick := formatTick(0.25, 0.05); tick != "0.25" {
+        t.Errorf("Got tick %s", tick)
+    }
+}
+
+func TestGenerate(t *testing.T) {
+    // The results of the paper in the result folder
+    out := t.TempDir()
+    files, err := Generate("../result", out, FormatAll, 0.95)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // battery, uav_available, share and compare_delay in SVG and PNG and the HTML
+    if len(files) != 9 {
+        t.Errorf("Got files %v", files)
+    }
+    page, err := os.ReadFile(filepath.Join(out, "report.html"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, want := range []string{"<svg", "RoundRobin", "EnergyAware", "Time delay (s)"} {
+        if !strings.Contains(string(page), want) {
+            t.Errorf("Missing %s in the report", want)
+        }
+    }
+
+    if _, err := Generate("../result", out, "gif", 0.95); err == nil {
+        t.Errorf("No error for an unknown format")
+    }
+}
@@ -0,0 +1,48 @@
+package report
+
+import (
+    "bytes"
+    "fmt"
+    "html"
+    "io"
+)
+
+// svgCanvas writes the elements of a chart in SVG
+type svgCanvas struct {
+    buf bytes.Buffer
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2 float64, color string, width float64, dashed bool) {
+    dash := ""
+    if dashed {
+        dash = ` stroke-dasharray="6,4"`
+    }
+    fmt.Fprintf(&s.buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%g"%s/>`+"\n", x1, y1, x2, y2, color, width, dash)
+}
+
+func (s *svgCanvas) rect(x, y, w, h float64, color string) {
+    fmt.Fprintf(&s.buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x, y, w, h, color)
+}
+
+func (s *svgCanvas) text(x, y float64, text string, size float64, anchor string, vertical bool) {
+    rotate := ""
+    if vertical {
+        rotate = fmt.Sprintf(` transform="rotate(-90 %.1f %.1f)"`, x, y)
+    }
+    fmt.Fprintf(&s.buf, `<text x="%.1f" y="%.1f" font-size="%g" text-anchor="%s"%s>%s</text>`+"\n", x, y, size, anchor, rotate, html.EscapeString(text))
+}
+
+// SVG is the chart as an SVG document
+func (c Chart) SVG() []byte {
+    s := &svgCanvas{}
+    fmt.Fprintf(&s.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", chartWidth, chartHeight, chartWidth, chartHeight)
+    c.draw(s)
+    s.buf.WriteString("</svg>\n")
+    return s.buf.Bytes()
+}
+
+// WriteSVG writes the chart in SVG
+func (c Chart) WriteSVG(w io.Writer) error {
+    _, err := w.Write(c.SVG())
+    return err
+}
@@ -0,0 +1,149 @@
+package scenario
+
+import (
+    "fmt"
+    "math/rand"
+    "sort"
+    "time"
+
+    "github.com/RezanOscar/COBRA/loadgen"
+    "github.com/RezanOscar/COBRA/simulator"
+)
+
+// Arrival is an arrival process of the tasks of a type of the workload, or of the whole workload without type
+// with the types drawn by their percentages. The tasks of all the processes are merged by arrival time
+type Arrival struct {
+    Type      string   `json:"type,omitempty" yaml:"type,omitempty"`
+    Process   string   `json:"process" yaml:"process"`                         // constant, poisson, mmpp, diurnal or flash
+    Rate      float64  `json:"rate" yaml:"rate"`                               // Tasks per second, of the calm state of mmpp and the mean of diurnal
+    BurstRate float64  `json:"burstRate,omitempty" yaml:"burstRate,omitempty"` // Tasks per second of the bursts of mmpp
+    Calm      Duration `json:"calm,omitempty" yaml:"calm,omitempty"`           // Mean time between two bursts of mmpp
+    Burst     Duration `json:"burst,omitempty" yaml:"burst,omitempty"`         // Mean time of a burst of mmpp
+    Amplitude float64  `json:"amplitude,omitempty" yaml:"amplitude,omitempty"` // Variation of the rate of diurnal, between 0 and 1
+    Period    Duration `json:"period,omitempty" yaml:"period,omitempty"`       // Period of diurnal
+    Peak      Duration `json:"peak,omitempty" yaml:"peak,omitempty"`           // Time of the max of diurnal in the period
+    Flashes   []Flash  `json:"flashes,omitempty" yaml:"flashes,omitempty"`     // Flash crowds of flash
+}
+
+// Flash is a flash crowd adding Rate tasks per second during Duration from At
+type Flash struct {
+    At       Duration `json:"at" yaml:"at"`
+    Duration Duration `json:"duration" yaml:"duration"`
+    Rate     float64  `json:"rate" yaml:"rate"`
+}
+
+// Trace is a file of task requests replayed instead of the workload, the times between the requests are
+// multiplied by Scale
+type Trace struct {
+    Path  string  `json:"path,omitempty" yaml:"path,omitempty"`
+    Scale float64 `json:"scale" yaml:"scale"`
+}
+
+// process converts the arrival to the process of the load generator
+func (a Arrival) process() loadgen.Process {
+    process := loadgen.Process{
+        Kind:      a.Process,
+        Rate:      a.Rate,
+        BurstRate: a.BurstRate,
+        CalmTime:  time.Duration(a.Calm),
+        BurstTime: time.Duration(a.Burst),
+        Amplitude: a.Amplitude,
+        Period:    time.Duration(a.Period),
+        Peak:      time.Duration(a.Peak),
+    }
+    for _, flash := range a.Flashes {
+        process.Flashes = append(process.Flashes, loadgen.Flash{At: time.Duration(flash.At), Duration: time.Duration(flash.Duration), Rate: flash.Rate})
+    }
+    return process
+}
+
+// validateArrivals returns the problems of the arrival processes
+func (s Scenario) validateArrivals() []string {
+    var problems []string
+    if len(s.Arrivals) > 0 && s.Rate > 0 {
+        problems = append(problems, "rate and arrivals cannot be both set, use a constant arrival")
+    }
+    if s.Trace.Path != "" && (len(s.Arrivals) > 0 || s.Rate > 0) {
+        problems = append(problems, "a trace cannot be replayed with a rate or arrivals")
+    }
+    if s.Trace.Scale <= 0 {
+        problems = append(problems, "the scale of the trace must be positive")
+    }
+    types := make(map[string]bool)
+    for _, workload := range s.Workload {
+        types[workload.Name] = true
+    }
+    for i, arrival := range s.Arrivals {
+        if arrival.Type != "" && !types[arrival.Type] {
+            problems = append(problems, fmt.Sprintf("arrival %d is of the type %s that is not in the workload", i+1, arrival.Type))
+        }
+        err := arrival.process().Validate()
+        if err != nil {
+            problems = append(problems, fmt.Sprintf("arrival %d: %v", i+1, err))
+        }
+    }
+    return problems
+}
+
+// Schedule returns the tasks to send in order and their arrival times since the start. In the closed loop the tasks
+// are the ones of TaskDistribution without arrival times. In the open loop the tasks of the arrival processes, or
+// of a constant process at the rate, are merged by arrival time and the first Tasks are sent, fewer if the processes
+// end before. The arrivals are drawn with the seed
+func (s Scenario) Schedule() ([]simulator.TaskType, []time.Duration) {
+    distribution := s.TaskDistribution()
+    arrivals := s.Arrivals
+    if len(arrivals) == 0 {
+        if s.Rate == 0 {
+            return distribution, nil
+        }
+        arrivals = []Arrival{{Process: loadgen.ProcessConstant, Rate: s.Rate}}
+    }
+
+    type arrival struct {
+        at       time.Duration
+        taskType simulator.TaskType
+    }
+    var merged []arrival
+    taskTypes := s.TaskTypes()
+    rnd := rand.New(rand.NewSource(s.Seed))
+    for _, process := range arrivals {
+        for i, at := range process.process().Arrivals(s.Tasks, rnd) {
+            // The processes of the whole workload take the types of the distribution in order
+            taskType := distribution[i]
+            for _, candidate := range taskTypes {
+                if candidate.Name == process.Type {
+                    taskType = candidate
+                }
+            }
+            merged = append(merged, arrival{at, taskType})
+        }
+    }
+    sort.SliceStable(merged, func(i, j int) bool { return merged[i].at < merged[j].at })
+    if len(merged) > s.Tasks {
+        merged = merged[:s.Tasks]
+    }
+
+    tasks := make([]simulator.TaskType, len(merged))
+    times := make([]time.Duration, len(merged))
+    for i, arrival := range merged {
+        tasks[i] = arrival.taskType
+        times[i] = arrival.at
+    }
+    return tasks, times
+}
+
+// Replay returns the tasks of the trace of the scenario and their arrival times with the scale, the costs of the
+// tasks are the ones of the records and the tasks option is ignored. The rejected records are not replayed
+func (s Scenario) Replay() ([]simulator.TaskType, []time.Duration, []loadgen.Rejection, error) {
+    records, rejected, err := loadgen.ReadTrace(s.Trace.Path)
+    if err != nil {
+        return nil, nil, rejected, err
+    }
+    tasks := make([]simulator.TaskType, len(records))
+    arrivals := make([]time.Duration, len(records))
+    for i, record := range records {
+        tasks[i] = simulator.TaskType{Name: record.TaskType, EnergyCost: record.EnergyCost, ComputeCost: record.ComputeCost}
+        arrivals[i] = time.Duration(float64(record.At) * s.Trace.Scale)
+    }
+    return tasks, arrivals, rejected, nil
+}
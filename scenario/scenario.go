@@ -0,0 +1,418 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Scenario of a simulation of the COBRA framework
+//
+// version : 1.1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - A scenario describes the fleet, the workload mix, the offload model (strategy) and
+//      the parameters of a simulation, in a YAML or JSON file
+//      - The flags of the command line override the values of the file, the scenario is
+//      validated and saved in the output directory with the results
+//      - arrivals.go : arrival processes of the tasks of the open loop, for the whole workload
+//      or by task type, and replay of the traces of task requests
+//      - sweep.go : grid of values of the parameters run with several seeds, to tune the
+//      offload models
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package scenario
+
+import (
+    "bytes"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "math/rand"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+
+    "github.com/RezanOscar/COBRA/ledger"
+    "github.com/RezanOscar/COBRA/scheduler"
+    "github.com/RezanOscar/COBRA/simulator"
+)
+
+// Modes of a simulation
+const (
+    ModeFabric = "fabric" // The tasks are sent to the ledger (Fabric network or memory backend)
+    ModeDES    = "des"    // Discrete-event simulation with a virtual clock
+)
+
+// Formats of the task log
+const (
+    TaskLogJSONL = "jsonl"
+    TaskLogCSV   = "csv"
+)
+
+// Scenario is the description of a simulation
+type Scenario struct {
+    Name                 string          `json:"name,omitempty" yaml:"name,omitempty"`
+    Mode                 string          `json:"mode" yaml:"mode"`
+    Strategy             string          `json:"strategy" yaml:"strategy"`                         // Offload function of the smart contract
+    Tasks                int             `json:"tasks" yaml:"tasks"`                               // Number of tasks to be sent
+    Clients              int             `json:"clients" yaml:"clients"`                           // Clients sending tasks at the same time, the max tasks in flight with a rate
+    Rate                 float64         `json:"rate" yaml:"rate"`                                 // Arrivals of tasks per second of the open loop, 0 for the closed loop
+    Arrivals             []Arrival       `json:"arrivals,omitempty" yaml:"arrivals,omitempty"`     // Arrival processes of the open loop, instead of the rate
+    Trace                Trace           `json:"trace" yaml:"trace"`                               // Trace of task requests replayed in open loop
+    MaxRetries           int             `json:"maxRetries" yaml:"maxRetries"`                     // Max attempts for a failed task
+    Backoff              Backoff         `json:"backoff" yaml:"backoff"`                           // Delay before the next attempt of a failed task
+    Lambda               float64         `json:"lambda" yaml:"lambda"`                             // Weight for reputation and previous reputation in TaskOffloadCobra
+    Epsilon              float64         `json:"epsilon" yaml:"epsilon"`                           // Weight for the energy priority in TaskOffloadCobra
+    ReportInterval       int             `json:"reportInterval" yaml:"reportInterval"`             // Intervals by task of the stats in the csv
+    ReportIntervalScreen int             `json:"reportIntervalScreen" yaml:"reportIntervalScreen"` // Intervals by task of the stats on the screen
+    Seed                 int64           `json:"seed" yaml:"seed"`
+    Workload             []Workload      `json:"workload" yaml:"workload"`
+    Fleet                simulator.Fleet `json:"fleet" yaml:"fleet"` // Devices of the discrete-event simulation
+    DES                  DES             `json:"des" yaml:"des"`
+    Ledger               ledger.Config   `json:"ledger" yaml:"ledger"`
+    Output               string          `json:"output" yaml:"output"` // Directory of the results
+    TaskLog              string          `json:"taskLog" yaml:"taskLog"` // Format of the log of each task in the output, jsonl or csv, empty for none
+    Metrics              string          `json:"metrics,omitempty" yaml:"metrics,omitempty"` // Address of the Prometheus /metrics endpoint, empty for none
+    Stats                Stats           `json:"stats" yaml:"stats"`   // Statistics of the final report
+    Sweep                Sweep           `json:"sweep" yaml:"sweep"`   // Grid of parameters of cobractl sweep
+}
+
+// Stats are the parameters of the statistics of the durations and the waits of the tasks in the final report
+type Stats struct {
+    Confidence float64 `json:"confidence" yaml:"confidence"` // Level of the confidence intervals, 0.95 for 95%
+    WarmUp     int     `json:"warmUp" yaml:"warmUp"`         // First finished tasks excluded from the statistics
+    Batches    int     `json:"batches" yaml:"batches"`       // Batches of the batch means confidence interval
+}
+
+// Workload is a task type with its proportion in % of the tasks
+type Workload struct {
+    Name        string  `json:"name" yaml:"name"`
+    EnergyCost  float64 `json:"energyCost" yaml:"energyCost"`
+    ComputeCost float64 `json:"computeCost" yaml:"computeCost"`
+    Percentage  int     `json:"percentage" yaml:"percentage"`
+}
+
+// Backoff is the jittered exponential delay before the next attempt of a failed task: drawn between 0 and Base
+// after the first attempt, 2 x Base after the second ... and at most Max
+type Backoff struct {
+    Base Duration `json:"base" yaml:"base"`
+    Max  Duration `json:"max" yaml:"max"`
+}
+
+// DES are the parameters of the discrete-event simulation
+type DES struct {
+    Latency Duration `json:"latency" yaml:"latency"` // Average commit time of a transaction
+    Jitter  Duration `json:"jitter" yaml:"jitter"`   // Variation of the commit time
+}
+
+// Default is the scenario of the paper: 2,000 tasks of the 6G use cases offloaded by COBRA on 3 EC and 27 UAV
+func Default() Scenario {
+    return Scenario{
+        Mode:                 ModeFabric,
+        Strategy:             "TaskOffloadCobra",
+        Tasks:                2000,
+        Clients:              1,
+        MaxRetries:           5,
+        Backoff:              Backoff{Base: Duration(100 * time.Millisecond), Max: Duration(5 * time.Second)},
+        Lambda:               0.3,
+        Epsilon:              0.7,
+        ReportInterval:       10,
+        ReportIntervalScreen: 100,
+        Seed:                 1,
+        Workload: []Workload{
+            {"IC", 2.2, 2.7, 15},    // Immersive Communication
+            {"HRLLC", 1.1, 1.9, 10}, // Hyper-Reliable and Low-Latency Communication
+            {"UC", 0.5, 0.9, 20},    // Ubiquitous Connectivity
+            {"MC", 0.9, 1.4, 15},    // Massive Communication
+            {"AIC", 2.7, 3.0, 15},   // AI and Communication
+            {"ISC", 1.2, 2.0, 25},   // Integrated Sensing and Communication
+        },
+        Fleet:  simulator.Fleet{EC: 3, UAV: 27},
+        DES:    DES{Latency: Duration(1800 * time.Millisecond), Jitter: Duration(300 * time.Millisecond)},
+        Ledger: ledger.DefaultConfig(),
+        Output: ".",
+        Trace:  Trace{Scale: 1},
+        Stats:  Stats{Confidence: 0.95, Batches: 20},
+        Sweep:  Sweep{Replications: 1},
+    }
+}
+
+// RegisterFlags adds the flags that override the scenario file, the current values are the defaults
+func (s *Scenario) RegisterFlags(flags *flag.FlagSet) {
+    flags.StringVar(&s.Mode, "mode", s.Mode, "fabric to send the tasks with the ledger client (see -backend), des for the discrete-event simulation")
+    flags.StringVar(&s.Strategy, "strategy", s.Strategy, "Offload function of the smart contract: "+strings.Join(simulator.Models, ", "))
+    flags.IntVar(&s.Tasks, "tasks", s.Tasks, "Number of tasks")
+    flags.IntVar(&s.Clients, "clients", s.Clients, "Clients sending tasks at the same time, the max tasks in flight with -rate")
+    flags.Float64Var(&s.Rate, "rate", s.Rate, "Tasks sent per second whatever the completions (open loop), 0 to send a task when a client is free")
+    flags.StringVar(&s.Trace.Path, "trace", s.Trace.Path, "CSV or JSONL trace of task requests replayed in open loop instead of the workload")
+    flags.Float64Var(&s.Trace.Scale, "trace-scale", s.Trace.Scale, "Factor of the inter-arrival times of the trace, 1 for the original times, 0.5 twice faster")
+    flags.IntVar(&s.MaxRetries, "retries", s.MaxRetries, "Max attempts for a failed task")
+    flags.Var(&s.Backoff.Base, "backoff", "Base of the jittered exponential backoff between the attempts of a failed task")
+    flags.Var(&s.Backoff.Max, "backoff-max", "Max backoff between the attempts of a failed task")
+    flags.Float64Var(&s.Lambda, "lambda", s.Lambda, "Weight for reputation and previous reputation in TaskOffloadCobra")
+    flags.Float64Var(&s.Epsilon, "epsilon", s.Epsilon, "Weight for the energy priority in TaskOffloadCobra")
+    flags.Int64Var(&s.Seed, "seed", s.Seed, "Seed of the task distribution and of the discrete-event simulation")
+    flags.IntVar(&s.Fleet.EC, "ecs", s.Fleet.EC, "Number of EC of the discrete-event simulation")
+    flags.IntVar(&s.Fleet.UAV, "uavs", s.Fleet.UAV, "Number of UAV of the discrete-event simulation")
+    flags.IntVar(&s.Fleet.HAPS, "haps", s.Fleet.HAPS, "Number of HAPS of the discrete-event simulation")
+    flags.IntVar(&s.Fleet.LEO, "leos", s.Fleet.LEO, "Number of LEO satellites of the discrete-event simulation")
+    flags.Var(&s.DES.Latency, "latency", "Average commit time of a transaction in the discrete-event simulation")
+    flags.Var(&s.DES.Jitter, "jitter", "Variation of the commit time in the discrete-event simulation")
+    flags.StringVar(&s.Output, "out", s.Output, "Directory of the results and of the scenario")
+    s.RegisterMetricsFlag(flags)
+    flags.StringVar(&s.TaskLog, "task-log", s.TaskLog, "Write the outcome of each task (TxID, device, times, attempts, error, battery) in jsonl or csv, empty for none")
+    flags.Float64Var(&s.Stats.Confidence, "confidence", s.Stats.Confidence, "Level of the confidence intervals of the report, 0.95 for 95%")
+    flags.IntVar(&s.Stats.WarmUp, "warmup", s.Stats.WarmUp, "First finished tasks excluded from the statistics of the report as the warm-up of the run")
+    flags.IntVar(&s.Stats.Batches, "batches", s.Stats.Batches, "Batches of the batch means confidence interval of the report")
+    s.Ledger.RegisterFlags(flags)
+}
+
+// RegisterMetricsFlag adds the flag of the metrics endpoint, also a global flag of cobractl
+func (s *Scenario) RegisterMetricsFlag(flags *flag.FlagSet) {
+    flags.StringVar(&s.Metrics, "metrics", s.Metrics, "Serve the Prometheus metrics on http://<address>/metrics, like :9100, empty for none")
+}
+
+// Resolve loads the scenario file of path, if any, and applies over it the flags set on the command line,
+// the scenario is then validated. With several flag sets bound to s, the flags of the last ones win
+func (s *Scenario) Resolve(path string, flagSets ...*flag.FlagSet) error {
+    if path != "" {
+        // The flags are bound to the fields of s, they are set again after the file is loaded
+        overrides := make([]map[string]string, len(flagSets))
+        for i, flags := range flagSets {
+            overrides[i] = make(map[string]string)
+            flags.Visit(func(f *flag.Flag) {
+                overrides[i][f.Name] = f.Value.String()
+            })
+        }
+
+        loaded, err := Load(path)
+        if err != nil {
+            return err
+        }
+        *s = loaded
+        for i, flags := range flagSets {
+            for name, value := range overrides[i] {
+                flags.Set(name, value)
+            }
+        }
+    }
+    return s.Validate()
+}
+
+// Load reads a scenario file, JSON if its extension is .json and YAML otherwise, the values missing in the
+// file are the ones of Default and the unknown keys are errors
+func Load(path string) (Scenario, error) {
+    s := Default()
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return s, fmt.Errorf("Failed to read the scenario: %w", err)
+    }
+
+    // The workload of the file replaces the one of Default, its task types are not merged
+    s.Workload = nil
+    if strings.EqualFold(filepath.Ext(path), ".json") {
+        decoder := json.NewDecoder(bytes.NewReader(data))
+        decoder.DisallowUnknownFields()
+        err = decoder.Decode(&s)
+    } else {
+        decoder := yaml.NewDecoder(bytes.NewReader(data))
+        decoder.KnownFields(true)
+        err = decoder.Decode(&s)
+    }
+    if err != nil {
+        return s, fmt.Errorf("Failed to decode the scenario %s: %w", path, err)
+    }
+    if s.Workload == nil {
+        s.Workload = Default().Workload
+    }
+    return s, nil
+}
+
+// Validate checks the scenario and returns all its problems in one error
+func (s Scenario) Validate() error {
+    var problems []string
+    problem := func(format string, args ...interface{}) {
+        problems = append(problems, fmt.Sprintf(format, args...))
+    }
+
+    if s.Mode != ModeFabric && s.Mode != ModeDES {
+        problem("mode %s must be %s or %s", s.Mode, ModeFabric, ModeDES)
+    }
+    validStrategy := false
+    for _, model := range simulator.Models {
+        validStrategy = validStrategy || s.Strategy == model
+    }
+    if !validStrategy {
+        problem("strategy %s must be one of %s", s.Strategy, strings.Join(simulator.Models, ", "))
+    }
+    if s.Tasks <= 0 || s.Clients <= 0 || s.MaxRetries <= 0 {
+        problem("tasks, clients and maxRetries must be positive")
+    }
+    if s.Rate < 0 {
+        problem("rate must not be negative")
+    }
+    if s.Backoff.Base < 0 || s.Backoff.Max < s.Backoff.Base {
+        problem("backoff base must not be negative and max must not be below it")
+    }
+    problems = append(problems, s.validateArrivals()...)
+    if s.ReportInterval <= 0 || s.ReportIntervalScreen <= 0 {
+        problem("reportInterval and reportIntervalScreen must be positive")
+    }
+    if s.Lambda < 0 || s.Lambda > 1 || s.Epsilon < 0 || s.Epsilon > 1 {
+        problem("lambda and epsilon must be between 0 and 1")
+    }
+
+    // The workload mix covers all the tasks with the task types of the smart contract
+    if len(s.Workload) == 0 {
+        problem("the workload has no task type")
+    }
+    total := 0
+    seen := make(map[string]bool)
+    for _, workload := range s.Workload {
+        if !scheduler.ValidTaskType(workload.Name) {
+            problem("unknown task type %s in the workload", workload.Name)
+        }
+        if seen[workload.Name] {
+            problem("task type %s is twice in the workload", workload.Name)
+        }
+        seen[workload.Name] = true
+        if workload.EnergyCost <= 0 || workload.ComputeCost <= 0 {
+            problem("the costs of %s must be positive", workload.Name)
+        }
+        if workload.Percentage < 0 {
+            problem("the percentage of %s must not be negative", workload.Name)
+        }
+        total += workload.Percentage
+    }
+    if total != 100 {
+        problem("the percentages of the workload sum to %d, not 100", total)
+    }
+
+    if s.Fleet.EC < 0 || s.Fleet.UAV < 0 || s.Fleet.HAPS < 0 || s.Fleet.LEO < 0 {
+        problem("the fleet must not have a negative number of devices")
+    }
+    if s.Fleet.Size() > simulator.MaxDevices {
+        problem("the fleet has %d devices, at most %d", s.Fleet.Size(), simulator.MaxDevices)
+    }
+    if s.Mode == ModeDES {
+        if s.Fleet.Size() == 0 {
+            problem("the fleet of the discrete-event simulation has no device")
+        }
+        if s.DES.Latency <= 0 || s.DES.Jitter < 0 || s.DES.Jitter > s.DES.Latency {
+            problem("des latency must be positive and jitter between 0 and the latency")
+        }
+    }
+    if s.Mode == ModeFabric && s.Ledger.Backend != ledger.BackendFabric && s.Ledger.Backend != ledger.BackendGateway && s.Ledger.Backend != ledger.BackendMemory {
+        problem("ledger backend %s must be %s, %s or %s", s.Ledger.Backend, ledger.BackendFabric, ledger.BackendGateway, ledger.BackendMemory)
+    }
+    if s.Output == "" {
+        problem("output must be a directory")
+    }
+    if s.TaskLog != "" && s.TaskLog != TaskLogJSONL && s.TaskLog != TaskLogCSV {
+        problem("taskLog %s must be %s, %s or empty", s.TaskLog, TaskLogJSONL, TaskLogCSV)
+    }
+    if s.Stats.Confidence <= 0 || s.Stats.Confidence >= 1 {
+        problem("stats confidence must be between 0 and 1 excluded")
+    }
+    if s.Stats.WarmUp < 0 || s.Stats.WarmUp >= s.Tasks {
+        problem("stats warmUp must be between 0 and the number of tasks")
+    }
+    if s.Stats.Batches < 2 {
+        problem("stats batches must be at least 2")
+    }
+    problems = append(problems, s.validateSweep()...)
+
+    if len(problems) > 0 {
+        return fmt.Errorf("Invalid scenario: %s", strings.Join(problems, "; "))
+    }
+    return nil
+}
+
+// TaskTypes returns the task types of the workload in the simulator format
+func (s Scenario) TaskTypes() []simulator.TaskType {
+    taskTypes := make([]simulator.TaskType, len(s.Workload))
+    for i, workload := range s.Workload {
+        taskTypes[i] = simulator.TaskType{Name: workload.Name, EnergyCost: workload.EnergyCost, ComputeCost: workload.ComputeCost}
+    }
+    return taskTypes
+}
+
+// TaskDistribution returns the tasks to send in a random order drawn with the seed, each type is in the
+// proportion of its percentage and the tasks left by the rounding are of the first type
+func (s Scenario) TaskDistribution() []simulator.TaskType {
+    taskTypes := s.TaskTypes()
+    tasks := make([]simulator.TaskType, 0, s.Tasks)
+    for i, workload := range s.Workload {
+        count := (workload.Percentage * s.Tasks) / 100
+        for j := 0; j < count; j++ {
+            tasks = append(tasks, taskTypes[i])
+        }
+    }
+
+    // Handle leftover tasks due to rounding issues
+    for len(tasks) < s.Tasks {
+        tasks = append(tasks, taskTypes[0])
+    }
+
+    // Shuffle tasks for randomness
+    rnd := rand.New(rand.NewSource(s.Seed))
+    rnd.Shuffle(len(tasks), func(i, j int) { tasks[i], tasks[j] = tasks[j], tasks[i] })
+    return tasks
+}
+
+// Save writes the scenario in YAML in the output directory, name is the file name without extension
+func (s Scenario) Save(name string) (string, error) {
+    err := os.MkdirAll(s.Output, 0755)
+    if err != nil {
+        return "", fmt.Errorf("Failed to create the output directory: %w", err)
+    }
+
+    data, err := yaml.Marshal(s)
+    if err != nil {
+        return "", fmt.Errorf("Failed to encode the scenario: %w", err)
+    }
+    path := filepath.Join(s.Output, name+".yaml")
+    err = os.WriteFile(path, data, 0644)
+    if err != nil {
+        return "", fmt.Errorf("Failed to save the scenario: %w", err)
+    }
+    return path, nil
+}
+
+// Duration is a time.Duration written like "1.8s" in the scenario files and the flags
+type Duration time.Duration
+
+func (d Duration) String() string {
+    return time.Duration(d).String()
+}
+
+// Set parses the value of a flag
+func (d *Duration) Set(value string) error {
+    parsed, err := time.ParseDuration(value)
+    if err != nil {
+        return err
+    }
+    *d = Duration(parsed)
+    return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+    return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+    var value string
+    err := json.Unmarshal(data, &value)
+    if err != nil {
+        return fmt.Errorf("Duration %s must be a string like \"1.8s\"", data)
+    }
+    return d.Set(value)
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+    return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+    return d.Set(value.Value)
+}
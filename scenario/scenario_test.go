@@ -0,0 +1,330 @@
+package scenario
+
+import (
+    "flag"
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), name)
+    err := os.WriteFile(path, []byte(content), 0644)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestDefaultValid(t *testing.T) {
+    err := Default().Validate()
+    if err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestDefaultFile(t *testing.T) {
+    // The example of the repository is the default scenario
+    s, err := Load("../scenarios/default.yaml")
+    if err != nil {
+        t.Fatal(err)
+    }
+    s.Name = ""
+    if !reflect.DeepEqual(s, Default()) {
+        t.Errorf("Got %+v, want %+v", s, Default())
+    }
+}
+
+func TestLoad(t *testing.T) {
+    yamlPath := writeFile(t, "scenario.yaml", `
+name: small
+strategy: TaskOffloadRandom
+tasks: 100
+workload:
+  - {name: UC, energyCost: 0.5, computeCost: 0.9, percentage: 60}
+  - {name: MC, energyCost: 0.9, computeCost: 1.4, percentage: 40}
+fleet: {ec: 1, uav: 4}
+des: {latency: 500ms, jitter: 100ms}
+`)
+    jsonPath := writeFile(t, "scenario.json", `{
+    "name": "small", "strategy": "TaskOffloadRandom", "tasks": 100,
+    "workload": [{"name": "UC", "energyCost": 0.5, "computeCost": 0.9, "percentage": 60},
+                 {"name": "MC", "energyCost": 0.9, "computeCost": 1.4, "percentage": 40}],
+    "fleet": {"ec": 1, "uav": 4}, "des": {"latency": "500ms", "jitter": "100ms"}}`)
+
+    fromYAML, err := Load(yamlPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    fromJSON, err := Load(jsonPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(fromYAML, fromJSON) {
+        t.Errorf("Got %+v from YAML and %+v from JSON", fromYAML, fromJSON)
+    }
+
+    // The values missing in the file are the defaults
+    if len(fromYAML.Workload) != 2 || fromYAML.Fleet.UAV != 4 || time.Duration(fromYAML.DES.Latency) != 500*time.Millisecond || fromYAML.MaxRetries != 5 || fromYAML.Ledger.Chaincode != "cobra_algo" {
+        t.Errorf("Got %+v", fromYAML)
+    }
+
+    _, err = Load(writeFile(t, "typo.yaml", "taks: 100\n"))
+    if err == nil {
+        t.Errorf("No error for an unknown key")
+    }
+}
+
+func TestValidate(t *testing.T) {
+    s := Default()
+    s.Workload[0].Percentage = 5
+    s.Strategy = "TaskOffloadBest"
+    s.Lambda = 2
+    s.TaskLog = "xml"
+    s.Stats.Confidence = 95
+    err := s.Validate()
+    if err == nil {
+        t.Fatal("No error for an invalid scenario")
+    }
+    for _, problem := range []string{"sum to 90", "strategy TaskOffloadBest", "lambda", "taskLog xml", "confidence"} {
+        if !strings.Contains(err.Error(), problem) {
+            t.Errorf("Got %v, want a problem with %s", err, problem)
+        }
+    }
+
+    s = Default()
+    s.Mode = ModeDES
+    s.Fleet.EC, s.Fleet.UAV = 0, 0
+    if s.Validate() == nil {
+        t.Errorf("No error for a simulation without device")
+    }
+    s.Fleet.UAV = 10000
+    if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "at most 9999") {
+        t.Errorf("Got %v, want a problem with the size of the fleet", err)
+    }
+}
+
+func TestResolve(t *testing.T) {
+    path := writeFile(t, "scenario.yaml", "tasks: 500\nclients: 4\nlambda: 0.5\n")
+
+    s := Default()
+    flags := flag.NewFlagSet("simulation", flag.ContinueOnError)
+    s.RegisterFlags(flags)
+    err := flags.Parse([]string{"-tasks", "100", "-latency", "2s", "-backend", "memory"})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // The flags set on the command line override the file
+    err = s.Resolve(path, flags)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if s.Tasks != 100 || s.Clients != 4 || s.Lambda != 0.5 || time.Duration(s.DES.Latency) != 2*time.Second || s.Ledger.Backend != "memory" {
+        t.Errorf("Got %+v", s)
+    }
+
+    // The global flags of a command are applied first, then the flags of its subcommand
+    s = Default()
+    global := flag.NewFlagSet("global", flag.ContinueOnError)
+    s.Ledger.RegisterFlags(global)
+    flags = flag.NewFlagSet("simulate", flag.ContinueOnError)
+    s.RegisterFlags(flags)
+    global.Parse([]string{"-channel", "channel1", "-user", "User1"})
+    flags.Parse([]string{"-user", "User2"})
+    err = s.Resolve(path, global, flags)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if s.Tasks != 500 || s.Ledger.Channel != "channel1" || s.Ledger.User != "User2" {
+        t.Errorf("Got %+v", s)
+    }
+}
+
+func TestTaskDistribution(t *testing.T) {
+    s := Default()
+    s.Tasks = 1003
+    tasks := s.TaskDistribution()
+    if len(tasks) != 1003 {
+        t.Fatalf("Got %d tasks, want 1003", len(tasks))
+    }
+
+    counts := make(map[string]int)
+    for _, task := range tasks {
+        counts[task.Name]++
+    }
+    // 15% of IC and the 3 tasks left by the rounding
+    if counts["IC"] != 153 || counts["ISC"] != 250 || counts["UC"] != 200 {
+        t.Errorf("Got %v", counts)
+    }
+
+    if !reflect.DeepEqual(tasks, s.TaskDistribution()) {
+        t.Errorf("Two distributions with the same seed differ")
+    }
+}
+
+func TestSchedule(t *testing.T) {
+    s := Default()
+    tasks, arrivals := s.Schedule()
+    if arrivals != nil || !reflect.DeepEqual(tasks, s.TaskDistribution()) {
+        t.Errorf("Got arrivals in the closed loop")
+    }
+
+    s.Tasks = 5
+    s.Rate = 4
+    want := []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond, time.Second}
+    if _, arrivals := s.Schedule(); !reflect.DeepEqual(arrivals, want) {
+        t.Errorf("Got %v, want %v", arrivals, want)
+    }
+
+    // IoT background traffic of the whole workload and a disaster surge of HRLLC tasks
+    s, err := Load(writeFile(t, "arrivals.yaml", `
+tasks: 2000
+arrivals:
+  - {process: poisson, rate: 2}
+  - {type: HRLLC, process: flash, rate: 0, flashes: [{at: 60s, duration: 10s, rate: 50}]}
+`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = s.Validate()
+    if err != nil {
+        t.Fatal(err)
+    }
+    tasks, arrivals = s.Schedule()
+    if len(tasks) != 2000 || len(arrivals) != 2000 {
+        t.Fatalf("Got %d tasks and %d arrivals, want 2000", len(tasks), len(arrivals))
+    }
+    surge := 0
+    for i, at := range arrivals {
+        if i > 0 && at < arrivals[i-1] {
+            t.Fatalf("The arrivals are not in order at %d", i)
+        }
+        if tasks[i].Name == "HRLLC" && at >= 60*time.Second && at < 70*time.Second {
+            surge++
+        }
+    }
+    // About 500 tasks of the surge and the 10% of HRLLC of the 20 background tasks
+    if surge < 400 || surge > 600 {
+        t.Errorf("Got %d HRLLC tasks during the surge, want about 500", surge)
+    }
+    again, _ := s.Schedule()
+    if !reflect.DeepEqual(tasks, again) {
+        t.Errorf("Two schedules with the same seed differ")
+    }
+
+    example, err := Load("../scenarios/disaster_response.yaml")
+    if err == nil {
+        err = example.Validate()
+    }
+    if err != nil {
+        t.Fatal(err)
+    }
+    if tasks, _ := example.Schedule(); len(tasks) != 50000 {
+        t.Errorf("Got %d tasks for the disaster response, want 50000", len(tasks))
+    }
+
+    s.Rate = 1
+    s.Arrivals = append(s.Arrivals, Arrival{Type: "XR", Process: "poisson", Rate: 1}, Arrival{Process: "mmpp", Rate: 1})
+    err = s.Validate()
+    if err == nil {
+        t.Fatal("No error for invalid arrivals")
+    }
+    for _, problem := range []string{"rate and arrivals", "type XR", "arrival 4"} {
+        if !strings.Contains(err.Error(), problem) {
+            t.Errorf("Got %v, want a problem with %s", err, problem)
+        }
+    }
+}
+
+func TestReplay(t *testing.T) {
+    s := Default()
+    s.Trace.Path = writeFile(t, "trace.csv", "timestamp,type,energyCost,computeCost\n10,UC,0.5,0.9\n12,XR,1,1\n14,ISC,1.5,2.5\n")
+    s.Trace.Scale = 0.5
+    err := s.Validate()
+    if err != nil {
+        t.Fatal(err)
+    }
+    tasks, arrivals, rejected, err := s.Replay()
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []time.Duration{0, 2 * time.Second}
+    if len(tasks) != 2 || tasks[1].Name != "ISC" || tasks[1].EnergyCost != 1.5 || !reflect.DeepEqual(arrivals, want) || len(rejected) != 1 || rejected[0].Line != 3 {
+        t.Errorf("Got tasks %v at %v and rejections %v", tasks, arrivals, rejected)
+    }
+
+    s.Rate = 2
+    s.Trace.Scale = 0
+    err = s.Validate()
+    if err == nil || !strings.Contains(err.Error(), "trace cannot") || !strings.Contains(err.Error(), "scale") {
+        t.Errorf("Got %v, want problems with the trace", err)
+    }
+}
+
+func TestSave(t *testing.T) {
+    s := Default()
+    s.Output = filepath.Join(t.TempDir(), "results")
+    path, err := s.Save("scenario_TaskOffloadCobra")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    saved, err := Load(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(s, saved) {
+        t.Errorf("Got %+v, want %+v", saved, s)
+    }
+}
+
+func TestGrid(t *testing.T) {
+    s := Default()
+    s.Sweep.Parameters.Set("lambda=0.1,0.5 strategy=TaskOffloadCobra,TaskOffloadECP,TaskOffloadRandom")
+    s.Sweep.Parameters.Set("lambda=0.2,0.4")
+    grid, err := s.Grid()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // The flags replace the axis of the same name, the first axis varies the slowest
+    if len(grid) != 6 || grid[0].Label() != "lambda-0.2_strategy-TaskOffloadCobra" || grid[5].Label() != "lambda-0.4_strategy-TaskOffloadRandom" {
+        t.Fatalf("Got %d configurations, from %s to %s", len(grid), grid[0].Label(), grid[len(grid)-1].Label())
+    }
+    if grid[5].Scenario.Lambda != 0.4 || grid[5].Scenario.Strategy != "TaskOffloadRandom" || len(grid[5].Scenario.Sweep.Parameters) != 0 {
+        t.Errorf("Got the scenario %+v", grid[5].Scenario)
+    }
+
+    s.Sweep.Parameters.Set("epsilon=0.5,2")
+    if _, err := s.Grid(); err == nil {
+        t.Errorf("No error for a configuration with an invalid epsilon")
+    }
+    s.Sweep.Parameters = Parameters{{Name: "lambda", Values: []string{"x"}}}
+    if _, err := s.Grid(); err == nil {
+        t.Errorf("No error for a value that is not a number")
+    }
+
+    s.Sweep.Parameters = Parameters{{Name: "seed", Values: []string{"1"}}, {Name: "speed", Values: []string{"1"}}}
+    s.Sweep.Replications = 0
+    err = s.Validate()
+    for _, problem := range []string{"seed cannot", "unknown parameter speed", "replications"} {
+        if err == nil || !strings.Contains(err.Error(), problem) {
+            t.Errorf("Got %v, want a problem with %s", err, problem)
+        }
+    }
+
+    // The example of the repository
+    s, err = Load("../scenarios/sweep_cobra.yaml")
+    if err != nil {
+        t.Fatal(err)
+    }
+    grid, err = s.Grid()
+    if err != nil || len(grid) != 9 || s.Sweep.Replications != 5 {
+        t.Errorf("Got %d configurations and %d replications (%v), want 9 and 5", len(grid), s.Sweep.Replications, err)
+    }
+}
@@ -0,0 +1,150 @@
+package scenario
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// Sweep is a grid of values of the flags of the scenario, each configuration of the grid is run Replications
+// times with the seeds Seed, Seed+1 ...
+type Sweep struct {
+    Parameters   Parameters `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+    Replications int        `json:"replications" yaml:"replications"`
+}
+
+// Parameter is an axis of the grid, the name of a flag of the scenario (lambda, epsilon, strategy, clients ...)
+// and its values as given on the command line
+type Parameter struct {
+    Name   string   `json:"name" yaml:"name"`
+    Values []string `json:"values" yaml:"values"`
+}
+
+// Parameters are the axes of the grid, also the value of the -vary flags written name=value1,value2
+type Parameters []Parameter
+
+func (p Parameters) String() string {
+    axes := make([]string, len(p))
+    for i, parameter := range p {
+        axes[i] = parameter.Name + "=" + strings.Join(parameter.Values, ",")
+    }
+    return strings.Join(axes, " ")
+}
+
+// Set adds the axes of a flag, an axis replaces the one of the same name so the flags override the file
+func (p *Parameters) Set(value string) error {
+    for _, axis := range strings.Fields(value) {
+        parts := strings.SplitN(axis, "=", 2)
+        if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+            return fmt.Errorf("Parameter %s must be written name=value1,value2", axis)
+        }
+        name := parts[0]
+        parameter := Parameter{Name: name, Values: strings.Split(parts[1], ",")}
+
+        replaced := false
+        for i := range *p {
+            if (*p)[i].Name == name {
+                (*p)[i] = parameter
+                replaced = true
+            }
+        }
+        if !replaced {
+            *p = append(*p, parameter)
+        }
+    }
+    return nil
+}
+
+// RegisterSweepFlags adds the flags of the sweep, the current values are the defaults
+func (s *Scenario) RegisterSweepFlags(flags *flag.FlagSet) {
+    flags.Var(&s.Sweep.Parameters, "vary", "Parameter of the sweep and its values, like lambda=0.1,0.3,0.5, repeat the flag for a grid")
+    flags.IntVar(&s.Sweep.Replications, "replications", s.Sweep.Replications, "Runs of each configuration of the sweep, with the seeds seed, seed+1 ...")
+}
+
+// validateSweep returns the problems of the sweep
+func (s Scenario) validateSweep() []string {
+    var problems []string
+    if s.Sweep.Replications <= 0 {
+        problems = append(problems, "the replications of the sweep must be positive")
+    }
+
+    flags := s.flagSet()
+    seen := make(map[string]bool)
+    for _, parameter := range s.Sweep.Parameters {
+        switch {
+        case parameter.Name == "seed":
+            problems = append(problems, "the seed cannot be a parameter of the sweep, the replications draw the seeds")
+        case flags.Lookup(parameter.Name) == nil:
+            problems = append(problems, fmt.Sprintf("unknown parameter %s in the sweep", parameter.Name))
+        case seen[parameter.Name]:
+            problems = append(problems, fmt.Sprintf("parameter %s is twice in the sweep", parameter.Name))
+        case len(parameter.Values) == 0:
+            problems = append(problems, fmt.Sprintf("parameter %s of the sweep has no value", parameter.Name))
+        }
+        seen[parameter.Name] = true
+    }
+    return problems
+}
+
+// Configuration is a point of the grid of a sweep, the values of its parameters and the scenario with these values
+type Configuration struct {
+    Names    []string
+    Values   []string
+    Scenario Scenario
+}
+
+// Label names the configuration by its values like lambda-0.3_epsilon-0.7, or base without parameter
+func (c Configuration) Label() string {
+    if len(c.Names) == 0 {
+        return "base"
+    }
+    parts := make([]string, len(c.Names))
+    for i, name := range c.Names {
+        parts[i] = name + "-" + c.Values[i]
+    }
+    return strings.Join(parts, "_")
+}
+
+// Grid returns the configurations of the sweep, the values of the first parameter vary the slowest. The scenarios
+// of the configurations have no sweep and are validated
+func (s Scenario) Grid() ([]Configuration, error) {
+    base := s
+    base.Sweep = Default().Sweep
+    configurations := []Configuration{{Scenario: base}}
+
+    for _, parameter := range s.Sweep.Parameters {
+        next := make([]Configuration, 0, len(configurations)*len(parameter.Values))
+        for _, configuration := range configurations {
+            for _, value := range parameter.Values {
+                scn := configuration.Scenario
+                err := scn.flagSet().Set(parameter.Name, value)
+                if err != nil {
+                    return nil, fmt.Errorf("Invalid value %s of the parameter %s: %w", value, parameter.Name, err)
+                }
+                next = append(next, Configuration{
+                    Names:    append(append([]string(nil), configuration.Names...), parameter.Name),
+                    Values:   append(append([]string(nil), configuration.Values...), value),
+                    Scenario: scn,
+                })
+            }
+        }
+        configurations = next
+    }
+
+    for _, configuration := range configurations {
+        err := configuration.Scenario.Validate()
+        if err != nil {
+            return nil, fmt.Errorf("Configuration %s: %w", configuration.Label(), err)
+        }
+    }
+    return configurations, nil
+}
+
+// flagSet returns the flags of the scenario bound to s, to set the parameters of the sweep
+func (s *Scenario) flagSet() *flag.FlagSet {
+    flags := flag.NewFlagSet("sweep", flag.ContinueOnError)
+    flags.SetOutput(io.Discard)
+    s.RegisterFlags(flags)
+    return flags
+}
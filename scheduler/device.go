@@ -0,0 +1,274 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Scheduling algorithms of the COBRA framework, shared by the smart contract, the
+//         offline simulation and the analysis tools
+//
+// version : 1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - device.go : devices, tasks and device classes with their energy model
+//      - lease.go : reservation of the compute resources, queues and reputation
+//      - strategy.go : the offload models (First Available, Round Robin, Random, ECP,
+//      Energy-Aware and COBRA) as pure functions over the devices and an explicit State
+//      - stats.go : aggregates of the fleet (batteries, tasks, energy, reputation)
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package scheduler
+
+import (
+    "fmt"
+    "hash/fnv"
+    "time"
+)
+
+// Device represents a UAV or Edge Server properties
+type Device struct {
+    DeviceID          string  `json:"deviceID"`
+    DeviceType        string  `json:"deviceType"`
+    Status            string  `json:"status"`
+    BatteryLife       float64 `json:"batteryLife"`
+    InitialBattery    float64 `json:"initialBattery"`
+    ComputeResources  float64 `json:"computeResources"`
+    InitialResources  float64 `json:"initialResources"`
+    TasksCompleted    int     `json:"tasksCompleted"`   // Total Task Completed
+    TotalTasks        int     `json:"totalTasks"`       // Total Task affect for an Device
+    TimeTasks         int     `json:"timeTasks"`        // Total Tasks realize in the time affect for the task
+    ComputeCostDevice float64 `json:"computeCostDevice"`// Total Compute Cost of task realize by an device
+    Reputation        float64 `json:"reputation"`       // Repuation
+    PreviousReputation        float64 `json:"previousreputation"`       // Repuation
+    EnergyConsumed    float64 `json:"energyConsumed"`   // Total energy in J consumed by the tasks of the device
+    Reservations      []Reservation `json:"reservations"` // Compute resources reserved by the running tasks
+    Queue             []QueuedTask  `json:"queue"`        // Tasks waiting for compute resources, in arrival order
+    Owner             string  `json:"owner"`            // MSP of the organization that registered the device, the only one allowed to change it
+}
+
+// Reservation represents the compute resources held by a running task until it completes or its lease expires
+type Reservation struct {
+    TaskID            string  `json:"taskID"`
+    ComputeCost       float64 `json:"computeCost"`
+    LeaseExpiry       int64   `json:"leaseExpiry"`      // End of the execution in Unix ms, the resources are released after it
+    Deadline          int64   `json:"deadline"`         // The task is on time if it completes before this Unix ms
+}
+
+// QueuedTask represents a task assigned to a device that waits for enough compute resources to start
+type QueuedTask struct {
+    TaskID            string  `json:"taskID"`
+    ComputeCost       float64 `json:"computeCost"`
+    Duration          int64   `json:"duration"`         // Execution time in ms drawn when the task was queued
+    EnqueuedAt        int64   `json:"enqueuedAt"`       // Unix ms of the assignment
+    Deadline          int64   `json:"deadline"`         // The waiting time counts in the deadline of the task
+}
+
+// Task represents the task details to be offloaded
+type Task struct {
+    TaskID       string  `json:"taskID"`
+    DeviceID     string  `json:"deviceID"`
+    TaskData     string  `json:"taskData"`
+    TaskType     string  `json:"taskType"`
+    EnergyCost   float64 `json:"energyCost"`
+    ComputeCost  float64 `json:"computeCost"`
+    EnergyConsumed float64 `json:"energyConsumed"` // Energy in J consumed by the device for the task
+    Status       string  `json:"status"`
+    StartTime    int64   `json:"startTime"`   // Unix ms of the assignment
+    LeaseExpiry  int64   `json:"leaseExpiry"` // Unix ms at which the task is completed if the device did not report it before
+}
+
+// DeviceClass represents the energy, capacity and latency characteristics shared by the devices of a type
+type DeviceClass struct {
+    Name              string  `json:"name"`
+    Infrastructure    bool    `json:"infrastructure"`    // Server side device prioritized like an EC (EC, LEO), otherwise handled like an UAV
+    Energy            EnergyModel `json:"energy"`        // Energy consumed by the tasks
+    MinBattery        float64 `json:"minBattery"`        // Battery under which the device becomes Unavailable
+    DefaultBattery    float64 `json:"defaultBattery"`    // Battery used when none is given at the registration
+    DefaultResources  float64 `json:"defaultResources"`  // Compute resources used when none are given at the registration
+    LatencyFactor     float64 `json:"latencyFactor"`     // Factor applied on the execution delay of a task
+    PropagationDelay  int     `json:"propagationDelay"`  // Delay in ms added to each task for the link to the device
+    VisibilityPeriod  int     `json:"visibilityPeriod"`  // Period in seconds of the visibility windows, 0 if the device is always visible
+    VisibilityWindow  int     `json:"visibilityWindow"`  // Duration in seconds of the visibility in each period
+    QueueCapacity     int     `json:"queueCapacity"`     // Maximum number of tasks waiting for the compute resources of a device
+}
+
+// EnergyModel computes the energy consumed by a task from its compute cycles, execution time and data size
+type EnergyModel struct {
+    Kappa             float64 `json:"kappa"`             // Effective switched capacitance of the CPU
+    CPUFrequency      float64 `json:"cpuFrequency"`      // CPU frequency in GHz
+    TxPower           float64 `json:"txPower"`           // Transmission power in W
+    DataRate          float64 `json:"dataRate"`          // Data rate of the link in Mbit/s
+    HoverPower        float64 `json:"hoverPower"`        // Power in W to stay in position during the task, 0 on the ground
+    JoulesPerUnit     float64 `json:"joulesPerUnit"`     // Energy in J of one unit of battery, 0 for a device on the grid
+}
+
+// CyclesPerComputeUnit is the number of CPU cycles for one unit of task ComputeCost
+const CyclesPerComputeUnit = 1e9
+
+// TaskDataSize is the data in MB sent with each type of task, the task types known by the scheduler
+var TaskDataSize = map[string]float64{
+    "IC":    10,  // Immersive Communication
+    "HRLLC": 0.5, // Hyper-Reliable and Low-Latency Communication
+    "UC":    2,   // Ubiquitous Connectivity
+    "MC":    1,   // Massive Communication
+    "AIC":   6,   // AI and Communication
+    "ISC":   4,   // Integrated Sensing and Communication
+}
+
+// Execution time in ms of each type of task (randomized delay) based on the paper "Ultra-reliable and low-latency communications:
+// applications, opportunities and challenges" by Daquan FENG 2021 and "Evolved Immersive Experience: Exploring 5G- and
+// Beyond-Enabled Ultra-Low-Latency Communications for Augmented and Virtual Reality" by Hazarika2023
+var executionTimes = map[string][2]int{
+    "IC":    {850, 1100},
+    "HRLLC": {50, 150},
+    "UC":    {700, 900},
+    "MC":    {550, 700},
+    "AIC":   {1400, 2100},
+    "ISC":   {400, 650},
+}
+
+// cobraExecutionTimes are the execution times with the COBRA model, the tasks are on the best device for their cost
+var cobraExecutionTimes = map[string][2]int{
+    "IC":    {650, 800},
+    "HRLLC": {50, 150},
+    "UC":    {500, 700},
+    "MC":    {350, 500},
+    "AIC":   {900, 1500},
+    "ISC":   {200, 450},
+}
+
+// ExecutionRange returns the min and max execution time in ms of a type of task, cobra for the COBRA model
+func ExecutionRange(taskType string, cobra bool) (int, int) {
+    times := executionTimes[taskType]
+    if cobra {
+        times = cobraExecutionTimes[taskType]
+    }
+    return times[0], times[1]
+}
+
+// ValidTaskType checks that the task type is one of the 6G use cases known by the scheduler
+func ValidTaskType(taskType string) bool {
+    _, ok := TaskDataSize[taskType]
+    return ok
+}
+
+// Consumption returns the energy in J used for a task: CPU (kappa * cycles * f^2), transmission of the data and hover
+func (m EnergyModel) Consumption(computeCost float64, duration time.Duration, dataSize float64) float64 {
+    cycles := computeCost * CyclesPerComputeUnit
+    frequency := m.CPUFrequency * 1e9
+    computeEnergy := m.Kappa * cycles * frequency * frequency
+
+    var transmissionTime float64
+    if m.DataRate > 0 {
+        transmissionTime = dataSize * 8 / m.DataRate
+    }
+    transmissionEnergy := m.TxPower * transmissionTime
+
+    // The device has to stay in position during the execution and the transmission
+    hoverEnergy := m.HoverPower * (duration.Seconds() + transmissionTime)
+
+    return computeEnergy + transmissionEnergy + hoverEnergy
+}
+
+// OnBattery checks if the tasks drain the battery of the devices of the class
+func (c DeviceClass) OnBattery() bool {
+    return c.Energy.JoulesPerUnit > 0
+}
+
+// energy returns the energy in J consumed by a task on a device of the class and the battery units it drains,
+// no battery is drained on the devices on the grid
+func (c DeviceClass) energy(taskType string, computeCost float64, duration time.Duration) (float64, float64) {
+    energyConsumed := c.Energy.Consumption(computeCost, duration, TaskDataSize[taskType])
+    if !c.OnBattery() {
+        return energyConsumed, 0
+    }
+    return energyConsumed, energyConsumed / c.Energy.JoulesPerUnit
+}
+
+// BatteryDrain returns the battery units a task of the type drains on a device of the class with the average
+// execution time, the drain used by Assign with the execution time drawn
+func (c DeviceClass) BatteryDrain(taskType string, computeCost float64) float64 {
+    minExecution, maxExecution := ExecutionRange(taskType, false)
+    execution := float64(minExecution+maxExecution)/2*c.LatencyFactor + float64(c.PropagationDelay)
+    _, drain := c.energy(taskType, computeCost, time.Duration(execution)*time.Millisecond)
+    return drain
+}
+
+// Classes are the device types that can be registered, a new class of device only has to be added here
+var Classes = map[string]DeviceClass{
+    // Edge Server on the ground, 10 times more powerful than an UAV
+    "EC": {
+        Name: "EC", Infrastructure: true, MinBattery: 0, DefaultBattery: 50, DefaultResources: 100,
+        LatencyFactor: 1, PropagationDelay: 0, QueueCapacity: 10,
+        Energy: EnergyModel{Kappa: 1e-28, CPUFrequency: 3, TxPower: 5, DataRate: 100, HoverPower: 0, JoulesPerUnit: 0},
+    },
+    // UAV (drone) with a small battery
+    "UAV": {
+        Name: "UAV", Infrastructure: false, MinBattery: 3, DefaultBattery: 50, DefaultResources: 10,
+        LatencyFactor: 1, PropagationDelay: 0, QueueCapacity: 3,
+        Energy: EnergyModel{Kappa: 1e-27, CPUFrequency: 1, TxPower: 1, DataRate: 20, HoverPower: 150, JoulesPerUnit: 250},
+    },
+    // High Altitude Platform Station, long endurance with solar panels and medium compute
+    "HAPS": {
+        Name: "HAPS", Infrastructure: false, MinBattery: 3, DefaultBattery: 200, DefaultResources: 40,
+        LatencyFactor: 1.2, PropagationDelay: 10, QueueCapacity: 5,
+        Energy: EnergyModel{Kappa: 1e-27, CPUFrequency: 2, TxPower: 5, DataRate: 50, HoverPower: 100, JoulesPerUnit: 2000},
+    },
+    // LEO satellite, only visible 10 minutes on each 95 minutes orbit and with a high latency
+    "LEO": {
+        Name: "LEO", Infrastructure: true, MinBattery: 3, DefaultBattery: 100, DefaultResources: 60,
+        LatencyFactor: 1, PropagationDelay: 60, VisibilityPeriod: 5700, VisibilityWindow: 600, QueueCapacity: 5,
+        Energy: EnergyModel{Kappa: 1e-28, CPUFrequency: 2, TxPower: 20, DataRate: 100, HoverPower: 0, JoulesPerUnit: 500},
+    },
+}
+
+// GetClass returns the class of a device type
+func GetClass(deviceType string) (DeviceClass, error) {
+    class, ok := Classes[deviceType]
+    if !ok {
+        return class, fmt.Errorf("Unknown device type %s", deviceType)
+    }
+    return class, nil
+}
+
+// IsVisible checks if a device is in one of its visibility windows at the time now
+func (c DeviceClass) IsVisible(deviceID string, now time.Time) bool {
+    if c.VisibilityPeriod <= 0 {
+        return true
+    }
+
+    // Each device has its own offset in the period so they are not all visible at the same time
+    hash := fnv.New32a()
+    hash.Write([]byte(deviceID))
+    offset := int64(hash.Sum32() % uint32(c.VisibilityPeriod))
+
+    return (now.Unix()+offset)%int64(c.VisibilityPeriod) < int64(c.VisibilityWindow)
+}
+
+// SplitDevices separates the infrastructure devices handled like ECs from the devices handled like UAVs, the
+// devices of an unknown type are dropped. Both share one array, the ECs before the UAVs
+func SplitDevices(devices []Device) ([]Device, []Device) {
+    kinds := make([]int8, len(devices)) // 0 for an unknown type, 1 for the ECs and 2 for the UAVs
+    known, ecCount := 0, 0
+    for i := range devices {
+        if _, ok := Classes[devices[i].DeviceType]; !ok {
+            continue
+        }
+        kinds[i] = 2
+        if Classes[devices[i].DeviceType].Infrastructure {
+            kinds[i] = 1
+            ecCount++
+        }
+        known++
+    }
+
+    split := make([]Device, known)
+    ecs, uavs := split[:0:ecCount], split[ecCount:ecCount]
+    for i := range devices {
+        switch kinds[i] {
+        case 1:
+            ecs = append(ecs, devices[i])
+        case 2:
+            uavs = append(uavs, devices[i])
+        }
+    }
+    return ecs, uavs
+}
@@ -0,0 +1,270 @@
+package scheduler
+
+import (
+    "errors"
+    "math/rand"
+    "time"
+)
+
+// ErrQueueFull is returned by Assign when the device can neither start nor queue the task
+var ErrQueueFull = errors.New("Device cannot queue the task")
+
+// Assign reserves the compute resources of the device for the task, or queues it if the device is saturated
+// or other tasks are already waiting. The task gives its ID, data, type and costs, the execution time is drawn
+// in [minExecution, maxExecution) ms and the returned task is Running or Queued
+func Assign(device *Device, task Task, minExecution int, maxExecution int, now time.Time, rnd *rand.Rand) (Task, error) {
+    class, err := GetClass(device.DeviceType)
+    if err != nil {
+        return task, err
+    }
+
+    // The execution time depends on the class of the device (slower compute, link to a satellite)
+    execution := float64(rnd.Intn(maxExecution-minExecution)+minExecution)*class.LatencyFactor + float64(class.PropagationDelay)
+    executionDuration := time.Duration(execution) * time.Millisecond
+
+    // The task is on time if it completes before the average execution time with a tolerance of 10%
+    avgExecution := float64(minExecution+maxExecution)/2*class.LatencyFactor + float64(class.PropagationDelay)
+    deadline := now.Add(time.Duration(avgExecution*1.1) * time.Millisecond)
+
+    // Reserve the ComputeCost until the task completes or its lease expires, or queue the task
+    status := "Running"
+    leaseExpiry := now.Add(executionDuration).UnixMilli()
+    if len(device.Queue) == 0 && device.ComputeResources >= task.ComputeCost {
+        device.Reservations = append(device.Reservations, Reservation{
+            TaskID:      task.TaskID,
+            ComputeCost: task.ComputeCost,
+            LeaseExpiry: leaseExpiry,
+            Deadline:    deadline.UnixMilli(),
+        })
+    } else {
+        if len(device.Queue) >= class.QueueCapacity || task.ComputeCost > device.InitialResources {
+            return task, ErrQueueFull
+        }
+        status = "Queued"
+        leaseExpiry = 0 // Known when the task starts
+        device.Queue = append(device.Queue, QueuedTask{
+            TaskID:      task.TaskID,
+            ComputeCost: task.ComputeCost,
+            Duration:    executionDuration.Milliseconds(),
+            EnqueuedAt:  now.UnixMilli(),
+            Deadline:    deadline.UnixMilli(),
+        })
+    }
+    device.ComputeResources = device.InitialResources - ReservedResources(*device)
+    device.ComputeCostDevice += task.ComputeCost
+    device.TotalTasks++
+    if device.ComputeResources < 3 {
+        device.Status = "Busy"
+    }
+
+    // Energy consumed with the model of the class, only drained from the battery for the devices on battery
+    energyConsumed, drain := class.energy(task.TaskType, task.ComputeCost, executionDuration)
+    device.EnergyConsumed += energyConsumed
+    if class.OnBattery() {
+        device.BatteryLife -= drain
+        if device.BatteryLife < class.MinBattery {
+            device.Status = "Unavailable"
+        }
+    }
+
+    task.DeviceID = device.DeviceID
+    task.EnergyConsumed = energyConsumed
+    task.Status = status
+    task.StartTime = now.UnixMilli()
+    task.LeaseExpiry = leaseExpiry
+    return task, nil
+}
+
+// Eligible checks if a device of the class can take a task of computeCost at the time now: it is visible, Available
+// or Busy, and it can start the task or queue it
+func (c DeviceClass) Eligible(device Device, computeCost float64, now time.Time) bool {
+    if device.Status != "Available" && device.Status != "Busy" || !c.IsVisible(device.DeviceID, now) {
+        return false
+    }
+    canStart := len(device.Queue) == 0 && device.ComputeResources >= computeCost
+    canQueue := len(device.Queue) < c.QueueCapacity && device.InitialResources >= computeCost
+    return canStart || canQueue
+}
+
+// ReleaseExpired frees the compute resources of the tasks whose lease expired, they are counted as completed at their lease expiry
+// and the queued tasks start in arrival order as soon as the released resources are enough for them
+func ReleaseExpired(device *Device, now time.Time) {
+    for {
+        // The running task that ends first
+        first := -1
+        for i, reservation := range device.Reservations {
+            if first < 0 || reservation.LeaseExpiry < device.Reservations[first].LeaseExpiry {
+                first = i
+            }
+        }
+        if first < 0 || device.Reservations[first].LeaseExpiry > now.UnixMilli() {
+            break
+        }
+
+        reservation := device.Reservations[first]
+        device.Reservations = append(device.Reservations[:first:first], device.Reservations[first+1:]...)
+        finishReservation(device, reservation, reservation.LeaseExpiry)
+        StartQueued(device, reservation.LeaseExpiry)
+    }
+    updateResources(device)
+}
+
+// Complete ends a running task of the device at the time now before its lease expires, the queued tasks can start
+// with the released resources. It returns false if the task is not running on the device
+func Complete(device *Device, taskID string, now time.Time) bool {
+    if state, _ := TaskState(*device, taskID); state != "Running" {
+        return false
+    }
+
+    var running []Reservation
+    for _, reservation := range device.Reservations {
+        if reservation.TaskID == taskID {
+            finishReservation(device, reservation, now.UnixMilli())
+        } else {
+            running = append(running, reservation)
+        }
+    }
+    device.Reservations = running
+    StartQueued(device, now.UnixMilli())
+    updateResources(device)
+    return true
+}
+
+// StartQueued starts at (Unix ms) the queued tasks in arrival order while the device has enough free resources
+func StartQueued(device *Device, at int64) {
+    for len(device.Queue) > 0 && device.InitialResources-ReservedResources(*device) >= device.Queue[0].ComputeCost {
+        queued := device.Queue[0]
+        device.Queue = device.Queue[1:]
+        device.Reservations = append(device.Reservations, Reservation{
+            TaskID:      queued.TaskID,
+            ComputeCost: queued.ComputeCost,
+            LeaseExpiry: at + queued.Duration,
+            Deadline:    queued.Deadline,
+        })
+    }
+    if len(device.Queue) == 0 {
+        device.Queue = nil
+    }
+}
+
+// TaskState returns the status and lease expiry of a task assigned to the device: Queued, Running or Completed
+func TaskState(device Device, taskID string) (string, int64) {
+    for _, queued := range device.Queue {
+        if queued.TaskID == taskID {
+            return "Queued", 0
+        }
+    }
+    for _, reservation := range device.Reservations {
+        if reservation.TaskID == taskID {
+            return "Running", reservation.LeaseExpiry
+        }
+    }
+    return "Completed", 0
+}
+
+// ExpectedWait estimates the wait in ms of a new task of computeCost behind the running and queued tasks of the device,
+// the running tasks end at their lease expiry and the queued tasks take their drawn execution time
+func ExpectedWait(device Device, computeCost float64, now time.Time) int64 {
+    if len(device.Queue) == 0 && device.ComputeResources >= computeCost {
+        return 0
+    }
+
+    clock := now.UnixMilli()
+    free := device.InitialResources - ReservedResources(device)
+    ends := append([]Reservation{}, device.Reservations...)
+
+    // Replay the queue in arrival order, each task starts when the earliest ends release enough resources
+    pending := append(append([]QueuedTask{}, device.Queue...), QueuedTask{ComputeCost: computeCost})
+    for i, queued := range pending {
+        for free < queued.ComputeCost && len(ends) > 0 {
+            first := 0
+            for j := range ends {
+                if ends[j].LeaseExpiry < ends[first].LeaseExpiry {
+                    first = j
+                }
+            }
+            if ends[first].LeaseExpiry > clock {
+                clock = ends[first].LeaseExpiry
+            }
+            free += ends[first].ComputeCost
+            ends = append(ends[:first:first], ends[first+1:]...)
+        }
+        if i < len(pending)-1 {
+            free -= queued.ComputeCost
+            ends = append(ends, Reservation{ComputeCost: queued.ComputeCost, LeaseExpiry: clock + queued.Duration})
+        }
+    }
+
+    return clock - now.UnixMilli()
+}
+
+// PreferIdle keeps the devices on which the task starts right away, all the devices are kept if they are all saturated
+func PreferIdle(devices []Device, computeCost float64) []Device {
+    idle := 0
+    for _, device := range devices {
+        if len(device.Queue) == 0 && device.ComputeResources >= computeCost {
+            idle++
+        }
+    }
+    if idle == 0 || idle == len(devices) {
+        return devices
+    }
+
+    selected := make([]Device, 0, idle)
+    for _, device := range devices {
+        if len(device.Queue) == 0 && device.ComputeResources >= computeCost {
+            selected = append(selected, device)
+        }
+    }
+    return selected
+}
+
+// ReservedResources returns the compute resources held by the running tasks of a device
+func ReservedResources(device Device) float64 {
+    var reserved float64
+    for _, reservation := range device.Reservations {
+        reserved += reservation.ComputeCost
+    }
+    return reserved
+}
+
+// UpdateReputation recalculates the reputation of the device every 5 tasks from the success and on-time rates of
+// its finished tasks, the previous reputation weighted by 1 - lambda
+func UpdateReputation(device *Device, lambda float64) {
+    if device.TotalTasks%5 != 0 {
+        return
+    }
+
+    // Save current reputation in PreviousReputation
+    device.PreviousReputation = device.Reputation
+
+    // Only the finished tasks are rated, ensure it is non-zero to avoid division by zero
+    totalTasks := device.TotalTasks - len(device.Reservations) - len(device.Queue)
+    if totalTasks == 0 {
+        totalTasks = 1 // Default to 1 if no tasks have been recorded yet
+    }
+
+    // Calculate the success rate: Completed tasks / Finished tasks
+    successRate := float64(device.TasksCompleted) / float64(totalTasks)
+
+    // Calculate the on-time task completion rate: On-time tasks / Finished tasks
+    timeRate := float64(device.TimeTasks) / float64(totalTasks)
+
+    device.Reputation = (lambda * (successRate + timeRate)) + ((1 - lambda) * float64(device.PreviousReputation))
+}
+
+// finishReservation updates the counters of the device for a task completed at end (Unix ms)
+func finishReservation(device *Device, reservation Reservation, end int64) {
+    device.TasksCompleted++
+    if end <= reservation.Deadline {
+        device.TimeTasks++ // Increment the count of on-time tasks
+    }
+}
+
+// updateResources sets the free compute resources of the device and makes it Available again when they are enough
+func updateResources(device *Device) {
+    device.ComputeResources = device.InitialResources - ReservedResources(*device)
+    if device.ComputeResources >= 3 && device.Status == "Busy" {
+        device.Status = "Available"
+    }
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+    "math"
+    "math/rand"
+    "testing"
+    "time"
+)
+
+func TestExpectedWait(t *testing.T) {
+    now := time.UnixMilli(10000)
+    device := Device{
+        InitialResources: 10,
+        Reservations: []Reservation{
+            {TaskID: "a", ComputeCost: 6, LeaseExpiry: 10500},
+            {TaskID: "b", ComputeCost: 4, LeaseExpiry: 10800},
+        },
+        Queue: []QueuedTask{{TaskID: "c", ComputeCost: 8, Duration: 300}},
+    }
+
+    // c starts when a and b end at 10800, a new task of 2 starts with it, a new task of 5 after c
+    if wait := ExpectedWait(device, 2, now); wait != 800 {
+        t.Errorf("Got wait %d, want 800", wait)
+    }
+    if wait := ExpectedWait(device, 5, now); wait != 1100 {
+        t.Errorf("Got wait %d, want 1100", wait)
+    }
+    if wait := ExpectedWait(Device{InitialResources: 10, ComputeResources: 10}, 5, now); wait != 0 {
+        t.Errorf("Got wait %d on an idle device, want 0", wait)
+    }
+}
+
+func TestAssignAndRelease(t *testing.T) {
+    now := time.UnixMilli(10000)
+    rnd := rand.New(rand.NewSource(1))
+    device := Device{DeviceID: "0001", DeviceType: "UAV", Status: "Available", BatteryLife: 50, InitialBattery: 50, ComputeResources: 10, InitialResources: 10}
+
+    first, err := Assign(&device, Task{TaskID: "a", TaskType: "HRLLC", ComputeCost: 8}, 50, 150, now, rnd)
+    if err != nil || first.Status != "Running" || device.ComputeResources != 2 || device.Status != "Busy" {
+        t.Fatalf("Got task %+v and device %+v, err %v", first, device, err)
+    }
+    second, err := Assign(&device, Task{TaskID: "b", TaskType: "HRLLC", ComputeCost: 5}, 50, 150, now, rnd)
+    if err != nil || second.Status != "Queued" || len(device.Queue) != 1 {
+        t.Fatalf("Got task %+v, want it queued, err %v", second, err)
+    }
+    if device.BatteryLife >= 50 || device.EnergyConsumed <= 0 {
+        t.Errorf("Got battery %.2f and energy %.2f, want the battery drained", device.BatteryLife, device.EnergyConsumed)
+    }
+
+    // At the lease expiry of a, b starts and a is completed
+    ReleaseExpired(&device, time.UnixMilli(first.LeaseExpiry))
+    if state, _ := TaskState(device, "b"); state != "Running" || device.TasksCompleted != 1 {
+        t.Errorf("Got state %s and device %+v, want b running and a completed", state, device)
+    }
+    if !Complete(&device, "b", time.UnixMilli(first.LeaseExpiry+10)) || device.ComputeResources != 10 || device.Status != "Available" {
+        t.Errorf("Got device %+v, want all the resources released", device)
+    }
+    if Complete(&device, "b", time.UnixMilli(first.LeaseExpiry+20)) {
+        t.Errorf("Completed b twice")
+    }
+}
+
+func TestAssignQueueFull(t *testing.T) {
+    now := time.UnixMilli(10000)
+    rnd := rand.New(rand.NewSource(1))
+    device := Device{DeviceID: "0001", DeviceType: "UAV", Status: "Available", BatteryLife: 50, InitialBattery: 50, ComputeResources: 10, InitialResources: 10}
+
+    for i := 0; i <= Classes["UAV"].QueueCapacity; i++ {
+        _, err := Assign(&device, Task{TaskID: string(rune('a' + i)), TaskType: "MC", ComputeCost: 10}, 550, 700, now, rnd)
+        if err != nil {
+            t.Fatalf("Task %d: %v", i, err)
+        }
+    }
+    _, err := Assign(&device, Task{TaskID: "z", TaskType: "MC", ComputeCost: 10}, 550, 700, now, rnd)
+    if err != ErrQueueFull {
+        t.Errorf("Got error %v, want ErrQueueFull", err)
+    }
+}
+
+func TestTaskCostIndex(t *testing.T) {
+    // The energy is weighted with 1 - epsilon and the compute with epsilon, both over a max cost of 3
+    if tci := TaskCostIndex(3, 0, 0.7); math.Abs(tci-0.3) > 1e-9 {
+        t.Errorf("Got TCI %.3f, want 0.3", tci)
+    }
+    if tci := TaskCostIndex(0, 1.5, 0.7); math.Abs(tci-0.35) > 1e-9 {
+        t.Errorf("Got TCI %.3f, want 0.35", tci)
+    }
+}
+
+func TestReliabilityIndex(t *testing.T) {
+    uav := Device{DeviceType: "UAV", BatteryLife: 25, InitialBattery: 50, ComputeResources: 5, InitialResources: 10, Reputation: 1, PreviousReputation: 1}
+    ec := Device{DeviceType: "EC", BatteryLife: 25, InitialBattery: 50, ComputeResources: 50, InitialResources: 100, Reputation: 1, PreviousReputation: 1}
+
+    // The battery ratio only counts for the devices on battery
+    if ri := ReliabilityIndex(uav, 0.3, 0.5); math.Abs(ri-1) > 1e-9 {
+        t.Errorf("Got RI %.3f for the UAV, want 1", ri)
+    }
+    if ri := ReliabilityIndex(ec, 0.3, 0.5); math.Abs(ri-0.75) > 1e-9 {
+        t.Errorf("Got RI %.3f for the EC, want 0.75", ri)
+    }
+}
+
+func TestCobraSelection(t *testing.T) {
+    now := time.UnixMilli(10000)
+    devices := []Device{
+        {DeviceID: "0001", DeviceType: "EC", Status: "Available", ComputeResources: 100, InitialResources: 100, Reputation: 1},
+        {DeviceID: "0002", DeviceType: "UAV", Status: "Available", BatteryLife: 50, InitialBattery: 50, ComputeResources: 10, InitialResources: 10, Reputation: 1},
+        {DeviceID: "0003", DeviceType: "UAV", Status: "Available", BatteryLife: 10, InitialBattery: 50, ComputeResources: 10, InitialResources: 10, Reputation: 1},
+    }
+    var state State
+
+    // A cheap task goes to the UAV with the most battery, an expensive one to the EC
+    if device := state.Cobra(devices, 0.5, 0.5, 0.3, 0.7, now); device.DeviceID != "0002" {
+        t.Errorf("Got device %s for a cheap task, want 0002", device.DeviceID)
+    }
+    if device := state.Cobra(devices, 3, 3, 0.3, 0.7, now); device.DeviceID != "0001" || state.LastUsedEC != "0001" {
+        t.Errorf("Got device %s for an expensive task, want 0001", device.DeviceID)
+    }
+    if device := state.Cobra(nil, 3, 3, 0.3, 0.7, now); device.DeviceID != "" {
+        t.Errorf("Got device %s without candidates, want none", device.DeviceID)
+    }
+}
+
+func TestRoundRobin(t *testing.T) {
+    devices := []Device{
+        {DeviceID: "0001", ComputeResources: 10},
+        {DeviceID: "0002", ComputeResources: 10},
+        {DeviceID: "0003", ComputeResources: 10},
+    }
+    var state State
+
+    var selected []string
+    for i := 0; i < 4; i++ {
+        selected = append(selected, state.RoundRobin(devices, 1).DeviceID)
+    }
+    if selected[0] != "0002" || selected[1] != "0003" || selected[2] != "0001" || selected[3] != "0002" {
+        t.Errorf("Got %v, want the devices in turn", selected)
+    }
+}
+
+func TestEnergyEfficiencyScore(t *testing.T) {
+    now := time.UnixMilli(10000)
+    class := Classes["UAV"]
+    drain := class.BatteryDrain("UC", 0.9)
+    if drain <= 0 || Classes["EC"].BatteryDrain("UC", 0.9) != 0 {
+        t.Fatalf("Got the drain %.3f of an UAV", drain)
+    }
+
+    // The drain of the score is the one of the assignment, up to the execution time drawn
+    device := Device{DeviceID: "0001", DeviceType: "UAV", Status: "Available", BatteryLife: 50, InitialBattery: 50, ComputeResources: 10, InitialResources: 10}
+    minExecution, maxExecution := ExecutionRange("UC", false)
+    Assign(&device, Task{TaskID: "a", TaskType: "UC", ComputeCost: 0.9}, minExecution, maxExecution, now, rand.New(rand.NewSource(1)))
+    if math.Abs(50-device.BatteryLife-drain) > 0.1 {
+        t.Errorf("Got the drain %.3f on the device, want about %.3f", 50-device.BatteryLife, drain)
+    }
+
+    // The UAV the task would drain under its minimum battery is penalized
+    low := Device{DeviceType: "UAV", BatteryLife: class.MinBattery + drain/2, ComputeResources: 10, InitialResources: 10}
+    high := low
+    high.BatteryLife = class.MinBattery + 2*drain
+    if gap := EnergyEfficiencyScore(high, "UC", 0.9, now) - EnergyEfficiencyScore(low, "UC", 0.9, now); gap < 10 {
+        t.Errorf("Got a gap of %.2f between the scores, want the penalty of the low battery", gap)
+    }
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+    "math"
+)
+
+// NetworkStats are the aggregates of the fleet computed by Stats, the UAV and EC fields are the ones
+// of the simulation and TypeStats has the same values for all the device types
+type NetworkStats struct {
+    Devices           int                  `json:"devices"`
+    AvgUAVBattery     float64              `json:"avgUAVBattery"`
+    AvailableUAVs     int                  `json:"availableUAVs"`
+    AvgComputeCost    float64              `json:"avgComputeCost"`     // Average ComputeCostDevice of all the devices
+    AvgComputeCostUAV float64              `json:"avgComputeCostUAV"`
+    AvgComputeCostEC  float64              `json:"avgComputeCostEC"`
+    AvgTasksUAV       float64              `json:"avgTasksUAV"`
+    AvgTasksEC        float64              `json:"avgTasksEC"`
+    TaskShareUAV      float64              `json:"taskShareUAV"`       // % of the tasks of the UAVs and ECs assigned to the UAVs
+    TaskShareEC       float64              `json:"taskShareEC"`
+    EnergyPerTaskUAV  float64              `json:"energyPerTaskUAV"`   // J
+    EnergyPerTaskEC   float64              `json:"energyPerTaskEC"`    // J
+    RunningTasks      int                  `json:"runningTasks"`
+    QueuedTasks       int                  `json:"queuedTasks"`
+    TypeStats         map[string]TypeStats `json:"typeStats"`
+    Reputation        ReputationStats      `json:"reputation"`
+}
+
+// TypeStats are the aggregates of the devices of a type
+type TypeStats struct {
+    Devices           int     `json:"devices"`
+    Available         int     `json:"available"`          // Available devices with battery left
+    AvgBattery        float64 `json:"avgBattery"`
+    AvgComputeCost    float64 `json:"avgComputeCost"`
+    AvgTasks          float64 `json:"avgTasks"`
+    TaskShare         float64 `json:"taskShare"`          // % of all the tasks
+    EnergyPerTask     float64 `json:"energyPerTask"`      // J
+}
+
+// typeTotals are the sums of the devices of a type used to compute their TypeStats
+type typeTotals struct {
+    battery           float64
+    computeCost       float64
+    tasks             int
+    energy            float64
+}
+
+// ReputationStats is the distribution of the reputation of the devices, Histogram counts the devices in the
+// buckets [0, 0.5), [0.5, 1), [1, 1.5), [1.5, 2) and [2, +inf)
+type ReputationStats struct {
+    Min               float64 `json:"min"`
+    Max               float64 `json:"max"`
+    Mean              float64 `json:"mean"`
+    StdDev            float64 `json:"stdDev"`
+    Histogram         []int   `json:"histogram"`
+}
+
+// Stats computes the aggregates of the devices, the ones returned by the GetNetworkStats of the smart contract
+func Stats(devices []Device) NetworkStats {
+    stats := NetworkStats{
+        TypeStats:  make(map[string]TypeStats),
+        Reputation: ReputationStats{Histogram: make([]int, 5)},
+    }
+
+    if len(devices) == 0 {
+        return stats
+    }
+
+    var totalComputeCost, totalReputation, totalReputationSquare float64
+    totalTasks := 0
+    totals := make(map[string]*typeTotals)
+    stats.Devices = len(devices)
+    stats.Reputation.Min = devices[0].Reputation
+    stats.Reputation.Max = devices[0].Reputation
+    for _, device := range devices {
+        // If the battery level is negative, treat it as 0
+        battery := math.Max(device.BatteryLife, 0)
+
+        typeStats := stats.TypeStats[device.DeviceType]
+        typeStats.Devices++
+        if device.Status == "Available" && battery > 0 {
+            typeStats.Available++
+        }
+        stats.TypeStats[device.DeviceType] = typeStats
+
+        if totals[device.DeviceType] == nil {
+            totals[device.DeviceType] = &typeTotals{}
+        }
+        total := totals[device.DeviceType]
+        total.battery += battery
+        total.computeCost += device.ComputeCostDevice
+        total.tasks += device.TotalTasks
+        total.energy += device.EnergyConsumed
+
+        totalComputeCost += device.ComputeCostDevice
+        totalTasks += device.TotalTasks
+        stats.RunningTasks += len(device.Reservations)
+        stats.QueuedTasks += len(device.Queue)
+
+        // Reputation distribution
+        totalReputation += device.Reputation
+        totalReputationSquare += device.Reputation * device.Reputation
+        stats.Reputation.Min = math.Min(stats.Reputation.Min, device.Reputation)
+        stats.Reputation.Max = math.Max(stats.Reputation.Max, device.Reputation)
+        bucket := int(math.Max(device.Reputation, 0) / 0.5)
+        if bucket >= len(stats.Reputation.Histogram) {
+            bucket = len(stats.Reputation.Histogram) - 1
+        }
+        stats.Reputation.Histogram[bucket]++
+    }
+
+    // Averages by type, the tasks and energy are divided by at least 1 to avoid division by zero
+    for deviceType, typeStats := range stats.TypeStats {
+        total := totals[deviceType]
+        typeStats.AvgBattery = total.battery / float64(typeStats.Devices)
+        typeStats.AvgComputeCost = total.computeCost / float64(typeStats.Devices)
+        typeStats.AvgTasks = float64(total.tasks) / float64(typeStats.Devices)
+        typeStats.TaskShare = float64(total.tasks) / math.Max(float64(totalTasks), 1) * 100
+        typeStats.EnergyPerTask = total.energy / math.Max(float64(total.tasks), 1)
+        stats.TypeStats[deviceType] = typeStats
+    }
+
+    uav := stats.TypeStats["UAV"]
+    ec := stats.TypeStats["EC"]
+    uavTasks, ecTasks := 0, 0
+    if totals["UAV"] != nil {
+        uavTasks = totals["UAV"].tasks
+    }
+    if totals["EC"] != nil {
+        ecTasks = totals["EC"].tasks
+    }
+    stats.AvgUAVBattery = uav.AvgBattery
+    stats.AvailableUAVs = uav.Available
+    stats.AvgComputeCost = totalComputeCost / float64(len(devices))
+    stats.AvgComputeCostUAV = uav.AvgComputeCost
+    stats.AvgComputeCostEC = ec.AvgComputeCost
+    stats.AvgTasksUAV = uav.AvgTasks
+    stats.AvgTasksEC = ec.AvgTasks
+    stats.EnergyPerTaskUAV = uav.EnergyPerTask
+    stats.EnergyPerTaskEC = ec.EnergyPerTask
+
+    // Share of the tasks between the UAVs and the ECs only, like the simulation
+    tasksUAVEC := math.Max(float64(uavTasks+ecTasks), 1)
+    stats.TaskShareUAV = float64(uavTasks) / tasksUAVEC * 100
+    stats.TaskShareEC = float64(ecTasks) / tasksUAVEC * 100
+
+    mean := totalReputation / float64(len(devices))
+    stats.Reputation.Mean = mean
+    stats.Reputation.StdDev = math.Sqrt(math.Max(totalReputationSquare/float64(len(devices))-mean*mean, 0))
+
+    return stats
+}
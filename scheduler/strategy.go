@@ -0,0 +1,285 @@
+package scheduler
+
+import (
+    "math"
+    "math/rand"
+    "time"
+)
+
+// State is the memory of the offload models between two tasks, kept by the caller (in the ledger by the smart
+// contract, in memory by a simulation)
+type State struct {
+    LastUsedEC      string `json:"lastUsedEC"`      // To track the last EC assigned a task
+    CurrentUAVTasks int    `json:"currentUAVTasks"` // To track how many UAV tasks are left in the current UAV phase
+}
+
+// The offload models select a device among the candidates of a task, they return a Device with an empty
+// DeviceID when there is no device to select
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// First Available, Round Robin and Random                                                     //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// FirstAvailable selects the first device, a device with a queue only if all are saturated
+func (s *State) FirstAvailable(devices []Device, computeCost float64) Device {
+    devices = PreferIdle(devices, computeCost)
+    if len(devices) == 0 {
+        return Device{}
+    }
+    return devices[0]
+}
+
+// RoundRobin selects the device after the last used one, the tasks are only queued when all the devices are saturated
+func (s *State) RoundRobin(devices []Device, computeCost float64) Device {
+    devices = PreferIdle(devices, computeCost)
+    if len(devices) == 0 {
+        return Device{}
+    }
+
+    // Initialize or reset the index if needed
+    if s.LastUsedEC == "" {
+        s.LastUsedEC = devices[0].DeviceID // Start with the first device
+    }
+
+    // Find the index of the last used device
+    var lastUsedIndex int
+    for i, device := range devices {
+        if device.DeviceID == s.LastUsedEC {
+            lastUsedIndex = i
+            break
+        }
+    }
+
+    // Select the next device in a round-robin fashion
+    selectedDevice := devices[(lastUsedIndex+1)%len(devices)]
+    s.LastUsedEC = selectedDevice.DeviceID
+    return selectedDevice
+}
+
+// Random selects a random device, the tasks are only queued when all the devices are saturated
+func (s *State) Random(devices []Device, computeCost float64, rnd *rand.Rand) Device {
+    return SelectRandomDevice(PreferIdle(devices, computeCost), rnd)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// ECP (Edge Server Prioritize)                                                                //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ECP selects first a random EC, but not consecutively, and if all ECs have completed their tasks,
+// UAVs handle 3 times the number of ECs tasks. ECs and LEO are handled as ECs, UAVs and HAPS as UAVs
+func (s *State) ECP(devices []Device, computeCost float64, rnd *rand.Rand) Device {
+    ecs, uavs := SplitDevices(devices)
+    ecs = PreferIdle(ecs, computeCost)
+    uavs = PreferIdle(uavs, computeCost)
+
+    // Check if we're in the UAV phase
+    if s.CurrentUAVTasks > 0 {
+        s.CurrentUAVTasks--
+        return SelectRandomDevice(uavs, rnd)
+    }
+
+    // Ensure no consecutive EC selection and check EC phase logic
+    if !AreAllECsAssigned(ecs) {
+        return s.SelectRandomEC(ecs, rnd)
+    }
+
+    // If all ECs have completed a task, switch to UAV phase
+    s.CurrentUAVTasks = len(ecs) * 3
+    s.CurrentUAVTasks--
+    return SelectRandomDevice(uavs, rnd)
+}
+
+// SelectRandomDevice randomly selects a device from the available devices
+func SelectRandomDevice(devices []Device, rnd *rand.Rand) Device {
+    if len(devices) == 0 {
+        return Device{}
+    }
+    return devices[rnd.Intn(len(devices))]
+}
+
+// SelectRandomEC ensures no consecutive EC selection and tracks the last assigned EC
+func (s *State) SelectRandomEC(ecs []Device, rnd *rand.Rand) Device {
+    var eligibleECs []Device
+    for _, ec := range ecs {
+        if ec.DeviceID != s.LastUsedEC { // Ensure we don't pick the last EC used
+            eligibleECs = append(eligibleECs, ec)
+        }
+    }
+
+    // If all ECs were previously used, reset the last used EC and pick a new one from the pool
+    if len(eligibleECs) == 0 {
+        s.LastUsedEC = "" // Reset, allowing any EC to be selected again
+        eligibleECs = ecs
+    }
+
+    selectedEC := SelectRandomDevice(eligibleECs, rnd)
+    s.LastUsedEC = selectedEC.DeviceID // Update the last used EC
+    return selectedEC
+}
+
+// AreAllECsAssigned checks if all ECs have been assigned at least once before switching to UAV phase
+func AreAllECsAssigned(ecs []Device) bool {
+    for _, ec := range ecs {
+        if ec.TotalTasks == 0 {
+            return false // If any EC hasn't been assigned a task, return false
+        }
+    }
+    return true // All ECs have been assigned a task
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Energy-Aware Task Scheduling proposed by Ningning Wang                                      //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// EnergyAware selects ECs first. Once all ECs have completed tasks, it switches to UAVs based on energy efficiency
+func (s *State) EnergyAware(devices []Device, taskType string, computeCost float64, now time.Time, rnd *rand.Rand) Device {
+    ecs, uavs := SplitDevices(devices)
+
+    // Check if we are in the UAV phase
+    if s.CurrentUAVTasks > 0 {
+        s.CurrentUAVTasks--
+        return SelectBestUAVByEnergyScore(uavs, taskType, computeCost, now)
+    }
+
+    // Prioritize ECs if available and they haven't all completed tasks
+    if !AreAllECsAssigned(ecs) {
+        return s.SelectRandomEC(ecs, rnd)
+    }
+
+    // Once all ECs have completed tasks, switch to UAVs
+    s.CurrentUAVTasks = len(ecs) * 3
+    s.CurrentUAVTasks-- // Decrease UAV task count
+    return SelectBestUAVByEnergyScore(uavs, taskType, computeCost, now)
+}
+
+// SelectBestUAVByEnergyScore selects the UAV with the highest energy efficiency score
+func SelectBestUAVByEnergyScore(uavs []Device, taskType string, computeCost float64, now time.Time) Device {
+    var selectedUAV Device
+    highestScore := math.Inf(-1)
+
+    for _, uav := range uavs {
+        score := EnergyEfficiencyScore(uav, taskType, computeCost, now)
+        if score > highestScore {
+            highestScore = score
+            selectedUAV = uav
+        }
+    }
+
+    return selectedUAV
+}
+
+// EnergyEfficiencyScore computes a score based on the device's battery life and compute resources.
+// Higher scores represent better candidates for task assignment, the battery drained by the task is the one
+// of the energy model of the class
+func EnergyEfficiencyScore(device Device, taskType string, computeCost float64, now time.Time) float64 {
+    batteryWeight := 0.75 // Prioritize battery life (since energy efficiency is key)
+    computeWeight := 0.25 // Compute resources are less important
+
+    // Calculate energy efficiency score as a weighted suma of battery life and compute resources
+    score := (batteryWeight * device.BatteryLife) + (computeWeight * device.ComputeResources)
+
+    // Penalize the devices on which the task would wait, 1 point for each 100 ms of expected wait
+    score -= float64(ExpectedWait(device, computeCost, now)) / 100
+
+    // Penalize UAVs the task would drain under their minimum battery to avoid rapid depletion
+    class, _ := GetClass(device.DeviceType)
+    if class.OnBattery() && device.BatteryLife-class.BatteryDrain(taskType, computeCost) < class.MinBattery {
+        score -= 10.0 // Arbitrary penalty for low battery UAVs
+    }
+
+    return score
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// COBRA Framework                                                                             //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//      CoBRA framework use TCI and RI to choose for each task the best Devices based          //
+//      on the porperties of the task                                                          //
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Cobra selects the device with the best RI, among the UAVs if the TCI of the task is low and the ECs otherwise
+func (s *State) Cobra(devices []Device, energyCost float64, computeCost float64, lambda float64, epsilon float64, now time.Time) Device {
+    ecs, uavs := SplitDevices(devices)
+
+    // If TCI is low, prefer UAVs, otherwise prefer ECs
+    tci := TaskCostIndex(energyCost, computeCost, epsilon)
+    if tci < 0.55 && len(uavs) > 0 {
+        return SelectBestDeviceByRI(uavs, lambda, epsilon, computeCost, now)
+    } else if len(ecs) > 0 {
+        return s.SelectBestECByRI(ecs, lambda, epsilon, computeCost, now)
+    }
+    return SelectBestDeviceByRI(uavs, lambda, epsilon, computeCost, now)
+}
+
+// ReliabilityIndex calculates RI based on the updated formula with the reputation
+func ReliabilityIndex(device Device, lambda float64, epsilon float64) float64 {
+    reputationScore := (lambda * float64(device.Reputation)) + ((1 - lambda) * float64(device.PreviousReputation))
+
+    // Calculate the resource availability ratio: Current compute resources / Initial compute resources
+    resourceRatio := device.ComputeResources / device.InitialResources
+
+    // Calculate battery life ratio: Current battery life / Initial battery life (only for the devices on battery)
+    var batteryRatio float64
+    class, _ := GetClass(device.DeviceType)
+    if class.OnBattery() && device.InitialBattery > 0 {
+        batteryRatio = math.Max(device.BatteryLife, 0) / device.InitialBattery // Ensure battery life is non-negative
+    } else {
+        batteryRatio = 0 // Battery ratio is ignored for ECs (λ = 0)
+    }
+
+    return epsilon*(batteryRatio+resourceRatio) + (1-epsilon)*reputationScore
+}
+
+// TaskCostIndex calculates TCI for a given task, the energy is weighted with 1 - epsilon
+func TaskCostIndex(energyCost float64, computeCost float64, epsilon float64) float64 {
+    maxEnergyCost := 3.0
+    maxComputeCost := 3.0
+
+    return (energyCost/maxEnergyCost)*(1-epsilon) + (computeCost/maxComputeCost)*epsilon
+}
+
+// WaitFactor divides the RI of a device by 1 + the expected wait in seconds of the task on it, so a device
+// on which the task would wait 1 s counts half
+func WaitFactor(device Device, computeCost float64, now time.Time) float64 {
+    return 1 + float64(ExpectedWait(device, computeCost, now))/1000
+}
+
+// SelectBestECByRI selects the best EC by RI, ensuring the last selected EC is not used consecutively
+func (s *State) SelectBestECByRI(ecs []Device, lambda float64, epsilon float64, computeCost float64, now time.Time) Device {
+    var bestEC Device
+    highestRI := -1.0
+
+    for _, ec := range ecs {
+        if ec.DeviceID != s.LastUsedEC { // Ensure it's not the last used EC
+            ri := ReliabilityIndex(ec, lambda, epsilon) / WaitFactor(ec, computeCost, now)
+            if ri > highestRI {
+                highestRI = ri
+                bestEC = ec
+            }
+        }
+    }
+
+    // If all ECs were excluded (e.g., LastUsedEC was the only one), fallback to the first EC
+    if bestEC.DeviceID == "" && len(ecs) > 0 {
+        bestEC = ecs[0]
+    }
+
+    s.LastUsedEC = bestEC.DeviceID // Update the last used EC
+    return bestEC
+}
+
+// SelectBestDeviceByRI selects the best device by RI (for UAVs)
+func SelectBestDeviceByRI(devices []Device, lambda float64, epsilon float64, computeCost float64, now time.Time) Device {
+    var bestDevice Device
+    highestRI := -1.0
+
+    for _, device := range devices {
+        ri := ReliabilityIndex(device, lambda, epsilon) / WaitFactor(device, computeCost, now)
+        if ri > highestRI {
+            highestRI = ri
+            bestDevice = device
+        }
+    }
+
+    return bestDevice
+}
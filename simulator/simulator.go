@@ -0,0 +1,609 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Discrete-event simulation of the task offload without a Fabric network
+//
+// version : 1.3
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - The offload models of the scheduler package run on an in-memory fleet with a virtual
+//      clock, so millions of tasks are simulated in seconds (BenchmarkRun)
+//      - Each client sends its tasks one after the other like cobractl simulate, a transaction is
+//      committed after a latency drawn around TxLatency and the failed tasks are retried after
+//      a jittered exponential backoff
+//      - With Arrivals the tasks arrive at their time whatever the completions (open loop), the
+//      tasks arriving when all the clients are busy wait for a free client in a FIFO
+//      - The devices are released and rated like in the smart contract, the devices that can take
+//      a task are indexed and only the devices whose lease expired are released (min-heap of
+//      the lease expiries) so a task does not walk the whole fleet, only the offload models
+//      read all their candidates
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package simulator
+
+import (
+    "container/heap"
+    "fmt"
+    "math"
+    "math/rand"
+    "sort"
+    "time"
+
+    "github.com/RezanOscar/COBRA/scheduler"
+)
+
+// Error codes of the smart contract returned by the simulated transactions
+const (
+    ErrNoCandidate         = "NO_CANDIDATE"
+    ErrInsufficientBattery = "INSUFFICIENT_BATTERY"
+    ErrUnknownTaskType     = "UNKNOWN_TASK_TYPE"
+    ErrConflict            = "CONFLICT"
+)
+
+// Models are the names of the smart contract functions that can be simulated
+var Models = []string{
+    "TaskOffloadFirstAvailable",
+    "TaskOffloadingRoundRobin",
+    "TaskOffloadRandom",
+    "TaskOffloadECP",
+    "TaskOffloadEnergyAware",
+    "TaskOffloadCobra",
+}
+
+// TaskType is a type of task with its costs
+type TaskType struct {
+    Name        string
+    EnergyCost  float64
+    ComputeCost float64
+}
+
+// Fleet is the number of devices of each class, registered with the default battery and resources of their class
+type Fleet struct {
+    EC   int `json:"ec" yaml:"ec"`
+    UAV  int `json:"uav" yaml:"uav"`
+    HAPS int `json:"haps" yaml:"haps"`
+    LEO  int `json:"leo" yaml:"leo"`
+}
+
+// MaxDevices is the largest fleet, the device IDs are 4 digits from 0001 to 9999 like in the ledger
+const MaxDevices = 9999
+
+// Size is the number of devices of the fleet
+func (f Fleet) Size() int {
+    return f.EC + f.UAV + f.HAPS + f.LEO
+}
+
+// Config are the parameters of a simulation
+type Config struct {
+    Model          string        // Name of the smart contract function
+    Tasks          []TaskType    // Tasks to send in order
+    Fleet          Fleet
+    Clients        int           // Clients sending tasks at the same time, 1 like cobractl simulate
+    Arrivals       []time.Duration // Arrival time of each task since the start in the open loop, nil for the closed loop
+    Lambda         float64       // Weight for reputation and previous reputation in TaskOffloadCobra
+    Epsilon        float64       // Weight for the energy priority in TaskOffloadCobra
+    MaxRetries     int           // Max attempts for a task
+    Backoff        Backoff       // Delay before the next attempt of a failed task
+    TxLatency      time.Duration // Average time between the submission and the commit of a transaction
+    TxJitter       time.Duration // The latency is drawn in [TxLatency - TxJitter, TxLatency + TxJitter]
+    ReportInterval int           // Stats of the fleet are reported each ReportInterval tasks
+    Seed           int64
+    Start          time.Time     // Virtual time of the beginning of the simulation
+}
+
+// TaskResult is the outcome of a task, Start and End are the virtual times of its last attempt
+type TaskResult struct {
+    TaskID      string
+    TaskType    string
+    DeviceID    string
+    DeviceType  string
+    BatteryLife float64 // Battery of the device after the assignment
+    Success     bool
+    Attempts    int
+    Arrival     time.Time // Arrival of the task, in the closed loop the time a client took it
+    Issued      time.Time // First attempt of the task, after the wait for a free client
+    Start       time.Time
+    End         time.Time
+    ErrorCode   string
+}
+
+// Report are the stats of the fleet after a number of finished tasks
+type Report struct {
+    Tasks   int
+    Elapsed time.Duration // Virtual time since the start
+    Stats   scheduler.NetworkStats
+}
+
+// Result is the outcome of a simulation
+type Result struct {
+    Reports []Report      // The first report is the initial state
+    Tasks   []TaskResult  // In the order they finished
+    Devices []scheduler.Device
+    Elapsed time.Duration // Virtual time of the simulation
+}
+
+// event is the commit of a transaction of a client, or the arrival of a task in the open loop
+type event struct {
+    at      time.Time
+    seq     int // Order of the events committed at the same time
+    arrival bool
+    client  int
+    task    int // Index of the task in Config.Tasks
+    attempt int
+    start   time.Time
+}
+
+// events is the queue of the events ordered by time
+type events []event
+
+func (q events) Len() int { return len(q) }
+func (q events) Less(i, j int) bool {
+    if q[i].at.Equal(q[j].at) {
+        return q[i].seq < q[j].seq
+    }
+    return q[i].at.Before(q[j].at)
+}
+func (q events) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *events) Push(x interface{}) { *q = append(*q, x.(event)) }
+func (q *events) Pop() interface{} {
+    old := *q
+    e := old[len(old)-1]
+    *q = old[:len(old)-1]
+    return e
+}
+
+// expiry is the earliest lease expiry (Unix ms) of the running tasks of a device
+type expiry struct {
+    at     int64
+    device int // Position of the device
+}
+
+// expiries is the queue of the lease expiries of the devices ordered by time
+type expiries []expiry
+
+func (q expiries) Len() int            { return len(q) }
+func (q expiries) Less(i, j int) bool  { return q[i].at < q[j].at }
+func (q expiries) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *expiries) Push(x interface{}) { *q = append(*q, x.(expiry)) }
+func (q *expiries) Pop() interface{} {
+    old := *q
+    e := old[len(old)-1]
+    *q = old[:len(old)-1]
+    return e
+}
+
+// simulation is the state of a running simulation
+type simulation struct {
+    config     Config
+    rnd        *rand.Rand
+    state      scheduler.State
+    devices    []scheduler.Device // The in-memory ledger, sorted by DeviceID like a range query
+    classes    []scheduler.DeviceClass // Class of each device
+    index      map[string]int     // Position of the devices by DeviceID
+    eligible   []int              // Positions of the Available or Busy devices with room in their queue, in order
+    candidates []scheduler.Device // Copy of the eligible devices, refreshed when a device changes
+    filtered   []scheduler.Device // Buffer of the candidates of a task when it cannot go on all the eligible devices
+    maxCost    float64            // Highest compute cost of the tasks
+    limited    []bool             // Eligible devices that cannot take all the tasks (visibility window, small resources)
+    nLimited   int
+    lowBattery []bool             // Devices made Unavailable by their battery
+    nLow       int
+    expiries   expiries           // Earliest lease expiry of the devices, the entries older than next are skipped
+    next       []int64            // Earliest lease expiry of each device in expiries, 0 for none
+    queue      events
+    seq        int
+    taskCount  int
+    arrived    []time.Time        // Arrival of each task
+    issued     []time.Time        // First attempt of each task
+}
+
+// Run simulates the offload of the tasks on the fleet with the model of the config
+func Run(config Config) (Result, error) {
+    var result Result
+    if !validModel(config.Model) {
+        return result, fmt.Errorf("Unknown model %s", config.Model)
+    }
+    if config.Clients <= 0 {
+        config.Clients = 1
+    }
+    if config.MaxRetries <= 0 {
+        config.MaxRetries = 1
+    }
+    if config.ReportInterval <= 0 {
+        config.ReportInterval = 10
+    }
+    if config.TxJitter > config.TxLatency {
+        config.TxJitter = config.TxLatency
+    }
+    if config.Start.IsZero() {
+        config.Start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    }
+    if config.Arrivals != nil && len(config.Arrivals) != len(config.Tasks) {
+        return result, fmt.Errorf("Got %d arrivals for %d tasks", len(config.Arrivals), len(config.Tasks))
+    }
+
+    s := &simulation{
+        config: config,
+        rnd:     rand.New(rand.NewSource(config.Seed)),
+        arrived: make([]time.Time, len(config.Tasks)),
+        issued:  make([]time.Time, len(config.Tasks)),
+    }
+    err := s.register()
+    if err != nil {
+        return result, err
+    }
+
+    result.Reports = append(result.Reports, Report{Stats: scheduler.Stats(s.devices)})
+    result.Tasks = make([]TaskResult, 0, len(config.Tasks))
+
+    // In the closed loop each client sends its first task at the start, in the open loop the tasks arrive at
+    // their time and the clients are free
+    next := 0
+    var free []int    // Clients without task of the open loop
+    var waiting []int // Tasks arrived when all the clients are busy, in the order of their arrival
+    if config.Arrivals == nil {
+        for client := 0; client < config.Clients && next < len(config.Tasks); client++ {
+            s.send(client, next, config.Start, config.Start)
+            next++
+        }
+    } else {
+        for client := config.Clients - 1; client >= 0; client-- {
+            free = append(free, client)
+        }
+        for task, at := range config.Arrivals {
+            s.seq++
+            heap.Push(&s.queue, event{at: config.Start.Add(at), seq: s.seq, arrival: true, task: task})
+        }
+    }
+
+    now := config.Start
+    for s.queue.Len() > 0 {
+        e := heap.Pop(&s.queue).(event)
+        now = e.at
+
+        if e.arrival {
+            if len(free) == 0 {
+                waiting = append(waiting, e.task)
+                continue
+            }
+            client := free[len(free)-1]
+            free = free[:len(free)-1]
+            s.send(client, e.task, now, now)
+            continue
+        }
+
+        taskResult, retry := s.commit(e)
+        if retry {
+            // Backoff before the next attempt like cobractl simulate
+            s.submit(e.client, e.task, e.attempt+1, now.Add(config.Backoff.Delay(e.attempt, s.rnd.Float64())))
+            continue
+        }
+        result.Tasks = append(result.Tasks, taskResult)
+
+        if len(result.Tasks)%config.ReportInterval == 0 {
+            result.Reports = append(result.Reports, Report{
+                Tasks:   len(result.Tasks),
+                Elapsed: now.Sub(config.Start),
+                Stats:   s.stats(now),
+            })
+        }
+
+        // The client sends its next task as soon as the previous one is finished
+        switch {
+        case config.Arrivals == nil && next < len(config.Tasks):
+            s.send(e.client, next, now, now)
+            next++
+        case len(waiting) > 0:
+            s.send(e.client, waiting[0], config.Start.Add(config.Arrivals[waiting[0]]), now)
+            waiting = waiting[1:]
+        case config.Arrivals != nil:
+            free = append(free, e.client)
+        }
+    }
+
+    s.releaseAll(now)
+    result.Devices = s.devices
+    result.Elapsed = now.Sub(config.Start)
+    return result, nil
+}
+
+// register creates the devices of the fleet like RegisterDeviceJSON, the IDs are drawn so the classes are mixed
+// in the order of the ledger like with cobractl devices register
+func (s *simulation) register() error {
+    var types []string
+    for _, class := range []struct {
+        name  string
+        count int
+    }{{"EC", s.config.Fleet.EC}, {"UAV", s.config.Fleet.UAV}, {"HAPS", s.config.Fleet.HAPS}, {"LEO", s.config.Fleet.LEO}} {
+        for i := 0; i < class.count; i++ {
+            types = append(types, class.name)
+        }
+    }
+    if len(types) == 0 {
+        return fmt.Errorf("No device in the fleet")
+    }
+    if len(types) > MaxDevices {
+        return fmt.Errorf("%d devices in the fleet, the device IDs allow %d", len(types), MaxDevices)
+    }
+
+    s.devices = make([]scheduler.Device, len(types))
+    s.classes = make([]scheduler.DeviceClass, len(types))
+    s.index = make(map[string]int, len(types))
+    for i, position := range s.rnd.Perm(len(types)) {
+        class, err := scheduler.GetClass(types[i])
+        if err != nil {
+            return err
+        }
+        s.devices[position] = scheduler.Device{
+            DeviceID:         fmt.Sprintf("%04d", position+1),
+            DeviceType:       class.Name,
+            Status:           "Available",
+            BatteryLife:      class.DefaultBattery,
+            InitialBattery:   class.DefaultBattery,
+            ComputeResources: class.DefaultResources,
+            InitialResources: class.DefaultResources,
+            Reputation:       1,
+        }
+        s.classes[position] = class
+    }
+    for _, task := range s.config.Tasks {
+        s.maxCost = math.Max(s.maxCost, task.ComputeCost)
+    }
+    s.limited = make([]bool, len(types))
+    s.lowBattery = make([]bool, len(types))
+    s.next = make([]int64, len(types))
+    for i, device := range s.devices {
+        s.index[device.DeviceID] = i
+        s.update(i)
+    }
+    return nil
+}
+
+// send sends the first attempt of a task arrived at the time arrival
+func (s *simulation) send(client int, task int, arrival time.Time, at time.Time) {
+    s.arrived[task] = arrival
+    s.issued[task] = at
+    s.submit(client, task, 1, at)
+}
+
+// submit sends an attempt of a task at the time at, it is committed after the latency of the network
+func (s *simulation) submit(client int, task int, attempt int, at time.Time) {
+    latency := s.config.TxLatency
+    if s.config.TxJitter > 0 {
+        latency += time.Duration(s.rnd.Int63n(int64(2*s.config.TxJitter)+1)) - s.config.TxJitter
+    }
+
+    s.seq++
+    heap.Push(&s.queue, event{
+        at:      at.Add(latency),
+        seq:     s.seq,
+        client:  client,
+        task:    task,
+        attempt: attempt,
+        start:   at,
+    })
+}
+
+// commit runs the transaction of the event on the ledger, retry is true if the task has to be sent again
+func (s *simulation) commit(e event) (TaskResult, bool) {
+    taskType := s.config.Tasks[e.task]
+    s.taskCount++
+    task := scheduler.Task{
+        TaskID:      fmt.Sprintf("%016x", s.taskCount), // Like a TxID, a new one for each attempt
+        TaskData:    fmt.Sprintf("%08x", e.task),
+        TaskType:    taskType.Name,
+        EnergyCost:  taskType.EnergyCost,
+        ComputeCost: taskType.ComputeCost,
+    }
+
+    deviceID, code := s.offload(task, e.at)
+    result := TaskResult{
+        TaskID:    task.TaskID,
+        TaskType:  task.TaskType,
+        DeviceID:  deviceID,
+        Success:   code == "",
+        Attempts:  e.attempt,
+        Arrival:   s.arrived[e.task],
+        Issued:    s.issued[e.task],
+        Start:     e.start,
+        End:       e.at,
+        ErrorCode: code,
+    }
+    if deviceID != "" {
+        device := s.devices[s.index[deviceID]]
+        result.DeviceType = device.DeviceType
+        result.BatteryLife = device.BatteryLife
+    }
+    retry := code != "" && IsRetryable(code) && e.attempt < s.config.MaxRetries
+    return result, retry
+}
+
+// offload selects a device with the model and assigns the task to it, it returns the device or the error code
+func (s *simulation) offload(task scheduler.Task, now time.Time) (string, string) {
+    if !scheduler.ValidTaskType(task.TaskType) {
+        return "", ErrUnknownTaskType
+    }
+
+    // Candidates of the task like getAvailableDevices of the smart contract, only the devices whose lease expired
+    // are released and the eligible devices are all the candidates unless the task cannot go on some of them
+    s.releaseAll(now)
+    candidates := s.candidates
+    if s.nLimited > 0 {
+        candidates = s.filter(task.ComputeCost, now)
+    }
+    if len(candidates) == 0 && s.nLow > 0 {
+        return "", ErrInsufficientBattery
+    }
+
+    var selected scheduler.Device
+    if len(candidates) > 0 {
+        selected = s.selectDevice(candidates, task, now)
+    }
+    if selected.DeviceID == "" {
+        return "", ErrNoCandidate
+    }
+
+    position := s.index[selected.DeviceID]
+    device := &s.devices[position]
+    cobra := s.config.Model == "TaskOffloadCobra"
+    minExecution, maxExecution := scheduler.ExecutionRange(task.TaskType, cobra)
+    _, err := scheduler.Assign(device, task, minExecution, maxExecution, now, s.rnd)
+    if err != nil {
+        return "", ErrConflict
+    }
+    if cobra {
+        scheduler.UpdateReputation(device, s.config.Lambda)
+    }
+    s.update(position)
+    return device.DeviceID, ""
+}
+
+// filter returns the eligible devices that can take a task of computeCost at the time now
+func (s *simulation) filter(computeCost float64, now time.Time) []scheduler.Device {
+    s.filtered = s.filtered[:0]
+    for j := range s.candidates {
+        if s.classes[s.eligible[j]].Eligible(s.candidates[j], computeCost, now) {
+            s.filtered = append(s.filtered, s.candidates[j])
+        }
+    }
+    return s.filtered
+}
+
+// selectDevice runs the model of the simulation on the candidates
+func (s *simulation) selectDevice(candidates []scheduler.Device, task scheduler.Task, now time.Time) scheduler.Device {
+    switch s.config.Model {
+    case "TaskOffloadFirstAvailable":
+        return s.state.FirstAvailable(candidates, task.ComputeCost)
+    case "TaskOffloadingRoundRobin":
+        return s.state.RoundRobin(candidates, task.ComputeCost)
+    case "TaskOffloadRandom":
+        return s.state.Random(candidates, task.ComputeCost, s.rnd)
+    case "TaskOffloadECP":
+        return s.state.ECP(candidates, task.ComputeCost, s.rnd)
+    case "TaskOffloadEnergyAware":
+        return s.state.EnergyAware(candidates, task.TaskType, task.ComputeCost, now, s.rnd)
+    default:
+        return s.state.Cobra(candidates, task.EnergyCost, task.ComputeCost, s.config.Lambda, s.config.Epsilon, now)
+    }
+}
+
+// update refreshes the indexes of the device at the position after a change: its copy in the candidates, the
+// low battery count and its earliest lease expiry
+func (s *simulation) update(position int) {
+    device := s.devices[position]
+    class := s.classes[position]
+
+    low := class.OnBattery() && device.Status == "Unavailable" && device.BatteryLife < class.MinBattery
+    if low != s.lowBattery[position] {
+        s.lowBattery[position] = low
+        if low {
+            s.nLow++
+        } else {
+            s.nLow--
+        }
+    }
+
+    // A device with room in its queue can take the tasks it has the resources for, without a visibility window
+    // and with the resources of all the tasks it takes them all
+    open := (device.Status == "Available" || device.Status == "Busy") && (len(device.Queue) == 0 || len(device.Queue) < class.QueueCapacity)
+    limit := device.InitialResources
+    if len(device.Queue) >= class.QueueCapacity {
+        limit = device.ComputeResources
+    }
+    limited := open && (class.VisibilityPeriod > 0 || limit < s.maxCost)
+    if limited != s.limited[position] {
+        s.limited[position] = limited
+        if limited {
+            s.nLimited++
+        } else {
+            s.nLimited--
+        }
+    }
+    j := sort.SearchInts(s.eligible, position)
+    member := j < len(s.eligible) && s.eligible[j] == position
+    switch {
+    case open && member:
+        s.candidates[j] = device
+    case open:
+        s.eligible = append(s.eligible, 0)
+        copy(s.eligible[j+1:], s.eligible[j:])
+        s.eligible[j] = position
+        s.candidates = append(s.candidates, scheduler.Device{})
+        copy(s.candidates[j+1:], s.candidates[j:])
+        s.candidates[j] = device
+    case member:
+        s.eligible = append(s.eligible[:j], s.eligible[j+1:]...)
+        s.candidates = append(s.candidates[:j], s.candidates[j+1:]...)
+    }
+
+    // The previous entry of the device stays in the queue when the new expiry is later, it is skipped
+    var earliest int64
+    for _, reservation := range device.Reservations {
+        if earliest == 0 || reservation.LeaseExpiry < earliest {
+            earliest = reservation.LeaseExpiry
+        }
+    }
+    if earliest != 0 && (s.next[position] == 0 || earliest < s.next[position]) {
+        s.next[position] = earliest
+        heap.Push(&s.expiries, expiry{at: earliest, device: position})
+    }
+}
+
+// stats returns the aggregates of the fleet at the time now like GetNetworkStats
+func (s *simulation) stats(now time.Time) scheduler.NetworkStats {
+    s.releaseAll(now)
+    return scheduler.Stats(s.devices)
+}
+
+// releaseAll releases the tasks ended at the time now like the queries of the smart contract, only on the devices
+// whose earliest lease expired. The counters of a released task do not depend on the time of the release
+func (s *simulation) releaseAll(now time.Time) {
+    for s.expiries.Len() > 0 && s.expiries[0].at <= now.UnixMilli() {
+        e := heap.Pop(&s.expiries).(expiry)
+        if e.at != s.next[e.device] {
+            continue
+        }
+        s.next[e.device] = 0
+        scheduler.ReleaseExpired(&s.devices[e.device], now)
+        s.update(e.device)
+    }
+}
+
+// IsRetryable checks if a task rejected with this code can succeed later, like the retry policy of cobractl
+func IsRetryable(code string) bool {
+    switch code {
+    case ErrInsufficientBattery, ErrUnknownTaskType:
+        return false
+    }
+    return true
+}
+
+// Backoff is the delay before the next attempt of a failed task, exponential from Base after the first attempt
+// and at most Max, with a full jitter so the clients rejected together do not send again at the same time
+type Backoff struct {
+    Base time.Duration
+    Max  time.Duration
+}
+
+// Delay is the delay after the attempt, drawn between 0 and its exponential ceiling with u uniform in [0, 1)
+func (b Backoff) Delay(attempt int, u float64) time.Duration {
+    ceiling := b.Base
+    for i := 1; i < attempt && ceiling < b.Max; i++ {
+        ceiling *= 2
+    }
+    if b.Max > 0 && ceiling > b.Max {
+        ceiling = b.Max
+    }
+    return time.Duration(u * float64(ceiling))
+}
+
+// validModel checks that the model is the name of an offload function of the smart contract
+func validModel(model string) bool {
+    for _, name := range Models {
+        if name == model {
+            return true
+        }
+    }
+    return false
+}
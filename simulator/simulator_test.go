@@ -0,0 +1,257 @@
+package simulator
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/RezanOscar/COBRA/scheduler"
+)
+
+// testConfig is a small fleet with the task mix of cobractl simulate
+func testConfig(model string, taskCount int) Config {
+    types := []TaskType{{"IC", 2.2, 2.7}, {"HRLLC", 1.1, 1.9}, {"UC", 0.5, 0.9}, {"MC", 0.9, 1.4}, {"AIC", 2.7, 3.0}, {"ISC", 1.2, 2.0}}
+    tasks := make([]TaskType, taskCount)
+    for i := range tasks {
+        tasks[i] = types[i%len(types)]
+    }
+    return Config{
+        Model:          model,
+        Tasks:          tasks,
+        Fleet:          Fleet{EC: 3, UAV: 27},
+        Clients:        1,
+        Lambda:         0.3,
+        Epsilon:        0.7,
+        MaxRetries:     5,
+        TxLatency:      1800 * time.Millisecond,
+        TxJitter:       300 * time.Millisecond,
+        ReportInterval: 10,
+        Seed:           1,
+    }
+}
+
+func TestRunModels(t *testing.T) {
+    for _, model := range Models {
+        result, err := Run(testConfig(model, 500))
+        if err != nil {
+            t.Fatalf("%s: %v", model, err)
+        }
+        if len(result.Tasks) != 500 || len(result.Reports) != 51 {
+            t.Errorf("%s: got %d tasks and %d reports, want 500 and 51", model, len(result.Tasks), len(result.Reports))
+        }
+
+        // Each task of the single client is committed after the previous one, around 1.8 s later
+        if result.Elapsed < 500*1500*time.Millisecond {
+            t.Errorf("%s: got a virtual time of %v for 500 tasks", model, result.Elapsed)
+        }
+
+        succeeded := 0
+        for _, task := range result.Tasks {
+            if task.Success {
+                succeeded++
+                if (task.DeviceType != "EC" && task.DeviceType != "UAV") || task.BatteryLife <= 0 {
+                    t.Fatalf("%s: got the task %+v without its device", model, task)
+                }
+            }
+        }
+        total := 0
+        for _, device := range result.Devices {
+            total += device.TotalTasks
+        }
+        if total != succeeded {
+            t.Errorf("%s: got %d tasks on the devices, want the %d successful tasks", model, total, succeeded)
+        }
+    }
+}
+
+func TestRunSeed(t *testing.T) {
+    first, err := Run(testConfig("TaskOffloadCobra", 300))
+    if err != nil {
+        t.Fatal(err)
+    }
+    second, _ := Run(testConfig("TaskOffloadCobra", 300))
+    if !reflect.DeepEqual(first.Tasks, second.Tasks) || !reflect.DeepEqual(first.Devices, second.Devices) {
+        t.Errorf("Two runs with the same seed differ")
+    }
+
+    config := testConfig("TaskOffloadCobra", 300)
+    config.Seed = 2
+    third, _ := Run(config)
+    if reflect.DeepEqual(first.Devices, third.Devices) {
+        t.Errorf("Two runs with different seeds are the same")
+    }
+}
+
+func TestRunClients(t *testing.T) {
+    single, _ := Run(testConfig("TaskOffloadRandom", 400))
+    config := testConfig("TaskOffloadRandom", 400)
+    config.Clients = 10
+    concurrent, err := Run(config)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // 10 clients send the same tasks in about a tenth of the time
+    if concurrent.Elapsed*5 > single.Elapsed {
+        t.Errorf("Got %v with 10 clients and %v with 1 client", concurrent.Elapsed, single.Elapsed)
+    }
+}
+
+func TestRunOpenLoop(t *testing.T) {
+    // A task every 100ms on 5 clients of tasks of 1.8s: the clients are saturated and the tasks wait
+    config := testConfig("TaskOffloadRandom", 200)
+    config.Clients = 5
+    config.Arrivals = make([]time.Duration, len(config.Tasks))
+    for i := range config.Arrivals {
+        config.Arrivals[i] = time.Duration(i) * 100 * time.Millisecond
+    }
+    saturated, err := Run(config)
+    if err != nil {
+        t.Fatal(err)
+    }
+    start := saturated.Tasks[0].Arrival.Add(-config.Arrivals[0])
+    lastWait := time.Duration(0)
+    for _, task := range saturated.Tasks {
+        wait := task.Issued.Sub(task.Arrival)
+        if wait < 0 || task.Arrival.Before(start) {
+            t.Fatalf("Got the task %+v", task)
+        }
+        if wait > lastWait {
+            lastWait = wait
+        }
+    }
+    if lastWait < 30*time.Second {
+        t.Errorf("Got a max wait of %v for 5 clients at 10 tasks/s", lastWait)
+    }
+
+    // With 40 clients the tasks do not wait and the simulation ends after the last arrival
+    config.Clients = 40
+    open, _ := Run(config)
+    for _, task := range open.Tasks {
+        if !task.Issued.Equal(task.Arrival) {
+            t.Fatalf("Task %s waited %v with 40 clients", task.TaskID, task.Issued.Sub(task.Arrival))
+        }
+    }
+    if open.Elapsed < 199*100*time.Millisecond || open.Elapsed > 30*time.Second {
+        t.Errorf("Got a virtual time of %v for the last arrival at 19.9s", open.Elapsed)
+    }
+
+    config.Arrivals = config.Arrivals[1:]
+    _, err = Run(config)
+    if err == nil {
+        t.Errorf("No error for missing arrivals")
+    }
+}
+
+func TestRunErrors(t *testing.T) {
+    _, err := Run(testConfig("TaskOffloadUnknown", 10))
+    if err == nil {
+        t.Errorf("No error for an unknown model")
+    }
+
+    config := testConfig("TaskOffloadCobra", 10)
+    config.Fleet = Fleet{}
+    _, err = Run(config)
+    if err == nil {
+        t.Errorf("No error for an empty fleet")
+    }
+    config.Fleet = Fleet{UAV: MaxDevices + 1}
+    _, err = Run(config)
+    if err == nil {
+        t.Errorf("No error for a fleet larger than the device IDs")
+    }
+
+    config = testConfig("TaskOffloadCobra", 1)
+    config.Tasks[0].Name = "XR"
+    result, _ := Run(config)
+    if result.Tasks[0].ErrorCode != ErrUnknownTaskType || result.Tasks[0].Attempts != 1 {
+        t.Errorf("Got %+v, want UNKNOWN_TASK_TYPE without retry", result.Tasks[0])
+    }
+}
+
+func TestRunCandidates(t *testing.T) {
+    // The satellites only take the tasks in their visibility window and the heavy tasks do not fit on the UAVs,
+    // so the tasks do not have the same candidates
+    for _, model := range Models {
+        config := testConfig(model, 2000)
+        config.Fleet = Fleet{EC: 1, UAV: 4, LEO: 3}
+        config.Clients = 20
+        config.TxLatency = 30 * time.Second
+        config.TxJitter = 10 * time.Second
+        for i := range config.Tasks {
+            if config.Tasks[i].Name == "AIC" {
+                config.Tasks[i].ComputeCost = 20
+            }
+        }
+        result, err := Run(config)
+        if err != nil {
+            t.Fatalf("%s: %v", model, err)
+        }
+
+        leo := scheduler.Classes["LEO"]
+        onLEO := 0
+        for _, task := range result.Tasks {
+            if !task.Success {
+                continue
+            }
+            if task.TaskType == "AIC" && task.DeviceType == "UAV" {
+                t.Fatalf("%s: the heavy task %s went on the UAV %s", model, task.TaskID, task.DeviceID)
+            }
+            if task.DeviceType == "LEO" {
+                onLEO++
+                if !leo.IsVisible(task.DeviceID, task.End) {
+                    t.Fatalf("%s: the task %s went on the satellite %s out of view", model, task.TaskID, task.DeviceID)
+                }
+            }
+        }
+        if onLEO == 0 && model != "TaskOffloadFirstAvailable" {
+            t.Errorf("%s: no task on the satellites", model)
+        }
+    }
+}
+
+func TestBackoff(t *testing.T) {
+    backoff := Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+    for _, c := range []struct {
+        attempt int
+        u       float64
+        want    time.Duration
+    }{
+        {1, 0.5, 50 * time.Millisecond},
+        {2, 0.5, 100 * time.Millisecond},
+        {4, 0.99, 792 * time.Millisecond},
+        {5, 0.5, 500 * time.Millisecond}, // 1.6 s capped at Max
+        {60, 0.5, 500 * time.Millisecond},
+        {3, 0, 0},
+    } {
+        if got := backoff.Delay(c.attempt, c.u); got != c.want {
+            t.Errorf("Got delay %s after attempt %d with %g, want %s", got, c.attempt, c.u, c.want)
+        }
+    }
+    if got := (Backoff{}).Delay(3, 0.5); got != 0 {
+        t.Errorf("Got delay %s without backoff", got)
+    }
+}
+
+// BenchmarkRun simulates b.N tasks with 100 clients on the fleet of the paper and on a fleet of 3000 devices, ns/op
+// is the time of a task: go test ./simulator -run XXX -bench 'Run/.*/30$' -benchtime 1000000x runs a million tasks
+func BenchmarkRun(b *testing.B) {
+    for _, fleet := range []Fleet{{EC: 3, UAV: 27}, {EC: 300, UAV: 2700}} {
+        for _, model := range Models {
+            b.Run(fmt.Sprintf("%s/%d", model, fleet.Size()), func(b *testing.B) {
+                config := testConfig(model, b.N)
+                config.Fleet = fleet
+                config.Clients = 100
+                config.ReportInterval = 1000
+                result, err := Run(config)
+                if err != nil {
+                    b.Fatal(err)
+                }
+                if len(result.Tasks) != b.N {
+                    b.Fatalf("Got %d tasks, want %d", len(result.Tasks), b.N)
+                }
+            })
+        }
+    }
+}
@@ -0,0 +1,251 @@
+/////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Objet : Statistics of the results of the simulation
+//
+// version : 1
+//
+// Author : Rêzan OSCAR
+// Infos :
+//      - Mean, sample standard deviation (n-1) and percentiles (p50, p90, p95, p99) interpolated
+//      between the sorted values
+//      - Confidence intervals of the mean with the Student t distribution at any confidence
+//      level, the previous version always used the normal 1.96 with the population variance
+//      - Batch means: the series of the tasks are autocorrelated (a long queue delays the next
+//      tasks), the CI is computed on the means of consecutive batches that are nearly independent
+//      - Warm-up: the first tasks of a run, before the queues and the batteries settle, can be
+//      excluded from the statistics
+//
+/////////////////////////////////////////////////////////////////////////////////////////////////
+
+package stats
+
+import (
+    "math"
+    "sort"
+)
+
+// Interval is a confidence interval of a mean
+type Interval struct {
+    Mean float64
+    Low  float64
+    High float64
+}
+
+// Summary are the statistics of a series of values
+type Summary struct {
+    Count   int
+    Mean    float64
+    StdDev  float64 // Sample standard deviation
+    Min     float64
+    Max     float64
+    P50     float64
+    P90     float64
+    P95     float64
+    P99     float64
+    CI      Interval // Student t interval of the mean
+    Batches int      // Batches of the batch means, 0 when there are too few values
+    BatchCI Interval // Interval of the batch means
+}
+
+// Summarize computes the statistics of values with the confidence intervals at level (0.95 for 95%) and the batch
+// means of batches batches
+func Summarize(values []float64, level float64, batches int) Summary {
+    s := Summary{Count: len(values)}
+    if len(values) == 0 {
+        return s
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    s.Mean = Mean(values)
+    s.StdDev = StdDev(values)
+    s.Min, s.Max = sorted[0], sorted[len(sorted)-1]
+    s.P50 = percentile(sorted, 50)
+    s.P90 = percentile(sorted, 90)
+    s.P95 = percentile(sorted, 95)
+    s.P99 = percentile(sorted, 99)
+    s.CI = CI(values, level)
+    if batches >= 2 && len(values) >= batches {
+        s.Batches = batches
+        s.BatchCI = BatchMeans(values, batches, level)
+    }
+    return s
+}
+
+// WarmUp returns the values after the first count ones, excluded as the warm-up period of the run
+func WarmUp(values []float64, count int) []float64 {
+    if count <= 0 {
+        return values
+    }
+    if count >= len(values) {
+        return nil
+    }
+    return values[count:]
+}
+
+// Mean is the mean of the values, 0 without value
+func Mean(values []float64) float64 {
+    if len(values) == 0 {
+        return 0
+    }
+    sum := 0.0
+    for _, value := range values {
+        sum += value
+    }
+    return sum / float64(len(values))
+}
+
+// StdDev is the sample standard deviation of the values, with n-1, 0 with less than 2 values
+func StdDev(values []float64) float64 {
+    if len(values) < 2 {
+        return 0
+    }
+    mean := Mean(values)
+    variance := 0.0
+    for _, value := range values {
+        variance += (value - mean) * (value - mean)
+    }
+    return math.Sqrt(variance / float64(len(values)-1))
+}
+
+// Percentile is the p-th percentile (0-100) of the values, interpolated between the two closest ranks
+func Percentile(values []float64, p float64) float64 {
+    if len(values) == 0 {
+        return 0
+    }
+    sorted := append([]float64(nil), values...)
+    sort.Float64s(sorted)
+    return percentile(sorted, p)
+}
+
+// percentile is the p-th percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+    rank := p / 100 * float64(len(sorted)-1)
+    low := int(math.Floor(rank))
+    if low < 0 {
+        return sorted[0]
+    }
+    if low >= len(sorted)-1 {
+        return sorted[len(sorted)-1]
+    }
+    return sorted[low] + (rank-float64(low))*(sorted[low+1]-sorted[low])
+}
+
+// CI is the confidence interval of the mean of the values at level with the Student t distribution, the interval
+// is the mean alone with less than 2 values
+func CI(values []float64, level float64) Interval {
+    mean := Mean(values)
+    n := len(values)
+    if n < 2 {
+        return Interval{mean, mean, mean}
+    }
+    margin := TQuantile(0.5+level/2, float64(n-1)) * StdDev(values) / math.Sqrt(float64(n))
+    return Interval{mean, mean - margin, mean + margin}
+}
+
+// BatchMeans is the confidence interval of the mean of an autocorrelated series: the values are split in batches
+// consecutive batches of the same size, the first values left over are dropped, and the interval is the one of
+// the means of the batches
+func BatchMeans(values []float64, batches int, level float64) Interval {
+    if batches < 2 || len(values) < batches {
+        return CI(values, level)
+    }
+    size := len(values) / batches
+    values = values[len(values)-size*batches:]
+    means := make([]float64, batches)
+    for i := range means {
+        means[i] = Mean(values[i*size : (i+1)*size])
+    }
+    return CI(means, level)
+}
+
+// TQuantile is the quantile p of the Student t distribution with df degrees of freedom, found by bisection of its
+// cumulative distribution
+func TQuantile(p float64, df float64) float64 {
+    if p == 0.5 {
+        return 0
+    }
+    if p < 0.5 {
+        return -TQuantile(1-p, df)
+    }
+    high := 1.0
+    for tCDF(high, df) < p && high < 1e12 {
+        high *= 2
+    }
+    low := 0.0
+    for i := 0; i < 100 && high-low > 1e-12; i++ {
+        mid := (low + high) / 2
+        if tCDF(mid, df) < p {
+            low = mid
+        } else {
+            high = mid
+        }
+    }
+    return (low + high) / 2
+}
+
+// tCDF is the cumulative distribution of the Student t distribution at t >= 0
+func tCDF(t float64, df float64) float64 {
+    return 1 - 0.5*incompleteBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// incompleteBeta is the regularized incomplete beta function I_x(a, b), with the continued fraction of Lentz
+func incompleteBeta(x float64, a float64, b float64) float64 {
+    if x <= 0 {
+        return 0
+    }
+    if x >= 1 {
+        return 1
+    }
+    lga, _ := math.Lgamma(a)
+    lgb, _ := math.Lgamma(b)
+    lgab, _ := math.Lgamma(a + b)
+    front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+    // The continued fraction converges quickly for x < (a+1)/(a+b+2), else with the symmetry
+    if x > (a+1)/(a+b+2) {
+        return 1 - front*betaFraction(1-x, b, a)/b
+    }
+    return front * betaFraction(x, a, b) / a
+}
+
+// betaFraction is the continued fraction of the incomplete beta function
+func betaFraction(x float64, a float64, b float64) float64 {
+    const tiny = 1e-300
+    c, d := 1.0, 1-(a+b)*x/(a+1)
+    if math.Abs(d) < tiny {
+        d = tiny
+    }
+    d = 1 / d
+    h := d
+    for m := 1; m <= 300; m++ {
+        fm := float64(m)
+        // Even step
+        num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+        d = 1 + num*d
+        if math.Abs(d) < tiny {
+            d = tiny
+        }
+        c = 1 + num/c
+        if math.Abs(c) < tiny {
+            c = tiny
+        }
+        d = 1 / d
+        h *= d * c
+        // Odd step
+        num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+        d = 1 + num*d
+        if math.Abs(d) < tiny {
+            d = tiny
+        }
+        c = 1 + num/c
+        if math.Abs(c) < tiny {
+            c = tiny
+        }
+        d = 1 / d
+        delta := d * c
+        h *= delta
+        if math.Abs(delta-1) < 1e-15 {
+            break
+        }
+    }
+    return h
+}
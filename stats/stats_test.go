@@ -0,0 +1,102 @@
+package stats
+
+import (
+    "math"
+    "math/rand"
+    "testing"
+)
+
+func near(a float64, b float64, tolerance float64) bool {
+    return math.Abs(a-b) <= tolerance
+}
+
+func TestTQuantile(t *testing.T) {
+    // Values of the tables of the Student t distribution
+    for _, c := range []struct {
+        p, df, want float64
+    }{
+        {0.975, 1, 12.7062},
+        {0.975, 9, 2.2622},
+        {0.975, 30, 2.0423},
+        {0.95, 9, 1.8331},
+        {0.995, 4, 4.6041},
+        {0.975, 1e6, 1.9600},
+        {0.025, 9, -2.2622},
+    } {
+        if got := TQuantile(c.p, c.df); !near(got, c.want, 1e-3) {
+            t.Errorf("TQuantile(%g, %g) = %.4f, want %.4f", c.p, c.df, got, c.want)
+        }
+    }
+}
+
+func TestSummarize(t *testing.T) {
+    values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+    if mean := Mean(values); mean != 5 {
+        t.Errorf("Got mean %g, want 5", mean)
+    }
+    // Sample standard deviation with n-1, the population one is 2
+    if stddev := StdDev(values); !near(stddev, math.Sqrt(32.0/7), 1e-12) {
+        t.Errorf("Got stddev %g, want %g", stddev, math.Sqrt(32.0/7))
+    }
+
+    if p := Percentile([]float64{5, 1, 4, 2, 3}, 50); p != 3 {
+        t.Errorf("Got p50 %g, want 3", p)
+    }
+    if p := Percentile([]float64{5, 1, 4, 2, 3}, 90); !near(p, 4.6, 1e-12) {
+        t.Errorf("Got p90 %g, want 4.6", p)
+    }
+
+    // The level changes the interval: 2.3646 for 95% and 3.4995 for 99% with 7 degrees of freedom
+    margin := math.Sqrt(32.0/7) / math.Sqrt(8)
+    ci95 := CI(values, 0.95)
+    ci99 := CI(values, 0.99)
+    if !near(ci95.High-5, 2.3646*margin, 1e-3) || !near(ci99.High-5, 3.4995*margin, 1e-3) || !near(ci95.Low, 10-ci95.High, 1e-12) {
+        t.Errorf("Got 95%% CI %+v and 99%% CI %+v", ci95, ci99)
+    }
+    if ci := CI([]float64{3}, 0.95); ci.Low != 3 || ci.High != 3 {
+        t.Errorf("Got CI %+v of one value, want the value", ci)
+    }
+
+    s := Summarize(values, 0.95, 4)
+    if s.Count != 8 || s.Min != 2 || s.Max != 9 || s.P50 != 4.5 || s.CI != ci95 || s.Batches != 4 {
+        t.Errorf("Got summary %+v", s)
+    }
+    if s := Summarize(values, 0.95, 20); s.Batches != 0 {
+        t.Errorf("Got %d batches of 8 values, want none", s.Batches)
+    }
+}
+
+func TestBatchMeans(t *testing.T) {
+    // The first values left over are dropped: 2 batches of 3 of the last 6 values
+    ci := BatchMeans([]float64{100, 1, 2, 3, 4, 5, 6}, 2, 0.95)
+    if ci.Mean != 3.5 {
+        t.Errorf("Got batch mean %g, want 3.5", ci.Mean)
+    }
+
+    // On an autocorrelated series the interval of the batch means is wider than the naive one
+    rnd := rand.New(rand.NewSource(1))
+    values := make([]float64, 10000)
+    value := 0.0
+    for i := range values {
+        value = 0.95*value + rnd.NormFloat64()
+        values[i] = value
+    }
+    naive := CI(values, 0.95)
+    batch := BatchMeans(values, 20, 0.95)
+    if batch.High-batch.Low <= 2*(naive.High-naive.Low) {
+        t.Errorf("Got batch means CI %+v not wider than the naive CI %+v", batch, naive)
+    }
+}
+
+func TestWarmUp(t *testing.T) {
+    values := []float64{9, 9, 1, 2}
+    if got := WarmUp(values, 2); len(got) != 2 || got[0] != 1 {
+        t.Errorf("Got %v after the warm-up of 2", got)
+    }
+    if got := WarmUp(values, 0); len(got) != 4 {
+        t.Errorf("Got %v without warm-up", got)
+    }
+    if got := WarmUp(values, 10); len(got) != 0 {
+        t.Errorf("Got %v after a warm-up longer than the values", got)
+    }
+}